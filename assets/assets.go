@@ -0,0 +1,10 @@
+// Package assets holds files embedded into the devtestrider binary.
+package assets
+
+import _ "embed"
+
+// Logo is the DevTestrider logo. Despite its name it is a JPEG; decode it
+// with image.Decode.
+//
+//go:embed logo.png
+var Logo []byte
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate the configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a config file for syntax errors, unknown keys and invalid values",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := defaultConfigPath
+		if opts, err := configOptions(cmd); err == nil {
+			path = opts.Path
+		}
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		if _, err := config.Load(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: OK\n", path)
+	},
+}
+
+var showEffective bool
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the configuration",
+	Long: `Print the configuration file merged over the defaults.
+
+With --effective, environment variables (DEVTESTRIDER_*) and command line
+flags are applied as well, and each value is annotated with its source:
+default, file, env or flag.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := configOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if !showEffective {
+			opts.Environ, opts.Flags = nil, nil
+		}
+
+		cfg, sources, err := config.Resolve(opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if !showEffective {
+			sources = nil
+		}
+
+		out, err := config.Effective(cfg, sources)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+		return nil
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for testrider.yml",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, err := config.Schema()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(schema))
+		return nil
+	},
+}
+
+func init() {
+	configShowCmd.Flags().BoolVar(&showEffective, "effective", false, "apply environment and flags and show the source of each value")
+	configCmd.AddCommand(configValidateCmd, configShowCmd, configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
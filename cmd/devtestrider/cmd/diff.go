@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ismailtsdln/DevTestrider/internal/analytics"
+	"github.com/ismailtsdln/DevTestrider/internal/console"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <base> <head>",
+	Short: "Compare two test runs",
+	Long: `Compare two test runs: new failures, fixed tests, added and removed tests
+and packages, and coverage and duration changes from base to head.
+
+A run is a JSON file, either saved in the history or written with
+--format json, or the ID of a run in the history. "latest" is the newest
+saved run and "latest~N" the one N runs before it. A run from the history
+is taken with the packages it did not test at their last result, so the
+partial runs of watch mode compare like full ones. A package deleted since
+is still taken at its last result, so it never shows as removed; compare
+JSON files of full runs for that:
+
+  devtestrider diff latest~1 latest
+
+To see what a branch changes compared to main, save a run of each:
+
+  git switch main && devtestrider run --format json > main.json
+  git switch feature && devtestrider run --format json > feature.json
+  devtestrider diff main.json feature.json
+
+With --format json the comparison is written as a single JSON object.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _ := loadConfig(cmd)
+		var store *history.Store
+		if cfg.History.Enable {
+			store = history.New(cfg.History.Dir, cfg.History.MaxRuns)
+		}
+
+		base, baseName, err := loadRun(store, args[0])
+		if err != nil {
+			return err
+		}
+		head, headName, err := loadRun(store, args[1])
+		if err != nil {
+			return err
+		}
+		comparison := analytics.Compare(base, head)
+
+		if machineReadable() {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Base string `json:"base"`
+				Head string `json:"head"`
+				*analytics.Comparison
+			}{baseName, headName, comparison})
+		}
+		out, err := console.New(os.Stdout, outputFormat)
+		if err != nil {
+			return err
+		}
+		out.Comparison(baseName, headName, comparison)
+		return nil
+	},
+}
+
+// loadRun reads the run ref names, a file or a history reference, and
+// returns it with the name to show for it. A run from the history includes
+// the last result of the packages it did not test.
+func loadRun(store *history.Store, ref string) (*engine.TestResult, string, error) {
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		result, err := history.ReadFile(ref)
+		return result, ref, err
+	}
+	if store == nil {
+		return nil, "", fmt.Errorf("%s: no such file, and the run history is disabled", ref)
+	}
+	id, err := store.Resolve(ref)
+	if err != nil {
+		return nil, "", err
+	}
+	runs, err := store.Until(id)
+	if err != nil {
+		return nil, "", err
+	}
+	return analytics.Snapshot(runs), id, nil
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
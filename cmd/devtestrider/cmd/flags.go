@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/console"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/orchestrator"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configFlags maps command line flags to the config keys they override.
+var configFlags = map[string]string{
+	"port":           "server.port",
+	"watch":          "watch.paths",
+	"ignore":         "watch.ignore",
+	"backend":        "watch.backend",
+	"poll-interval":  "watch.poll_interval",
+	"report-format":  "report.formats",
+	"report-dir":     "report.output_dir",
+	"notify":         "notifications.enable",
+	"notify-channel": "notifications.channels",
+	"profile":        "profile",
+	"git":            "git.enable",
+}
+
+var (
+	cfgFile      string
+	setOverride  []string
+	outputFormat string
+)
+
+func init() {
+	f := rootCmd.PersistentFlags()
+	f.StringVarP(&cfgFile, "config", "c", "", "config file (default \"testrider.yml\", env DEVTESTRIDER_CONFIG)")
+	f.Int("port", 0, "dashboard server port")
+	f.StringSlice("watch", nil, "paths to watch")
+	f.StringSlice("ignore", nil, "path substrings to ignore")
+	f.String("backend", "", "watch backend: auto, fsnotify or polling")
+	f.Duration("poll-interval", 0, "scan interval of the polling backend")
+	f.StringSlice("report-format", nil, "report formats to generate")
+	f.String("report-dir", "", "directory reports are written to")
+	f.Bool("notify", false, "send notifications")
+	f.StringSlice("notify-channel", nil, "notification channels")
+	f.String("profile", "", "test profile to use")
+	f.Bool("git", false, "react to git HEAD moves")
+	f.StringArrayVar(&setOverride, "set", nil, "override any config key, e.g. --set watch.poll_interval=2s (repeatable)")
+	f.StringVar(&outputFormat, "format", console.FormatPlain, "terminal output: "+strings.Join(console.Formats, ", "))
+}
+
+// newOutput creates the terminal output selected with --format.
+func newOutput() (orchestrator.Output, error) {
+	switch outputFormat {
+	case console.FormatJSON, console.FormatEvents:
+		return console.NewJSON(os.Stdout, outputFormat == console.FormatEvents), nil
+	}
+	return console.New(os.Stdout, outputFormat)
+}
+
+// machineReadable reports whether --format reserves stdout for JSON records,
+// so everything else must go to stderr.
+func machineReadable() bool {
+	return outputFormat == console.FormatJSON || outputFormat == console.FormatEvents
+}
+
+// progressOf returns the live event hook of outputs that have one.
+func progressOf(out orchestrator.Output) func(engine.GoTestEvent) {
+	if p, ok := out.(interface{ Progress(engine.GoTestEvent) }); ok {
+		return p.Progress
+	}
+	return nil
+}
+
+// configOptions collects the config file path, environment and the flags the
+// user actually set into the layers resolved by config.Resolve.
+func configOptions(cmd *cobra.Command) (config.Options, error) {
+	opts := config.Options{
+		Path:    defaultConfigPath,
+		Environ: os.Environ(),
+		Flags:   make(map[string]string),
+	}
+	if env := os.Getenv(config.EnvPrefix + "CONFIG"); env != "" {
+		opts.Path, opts.Explicit = env, true
+	}
+	if cfgFile != "" {
+		opts.Path, opts.Explicit = cfgFile, true
+	}
+
+	for _, kv := range setOverride {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return opts, fmt.Errorf("--set %q: want key=value", kv)
+		}
+		opts.Flags[key] = value
+	}
+
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		key, ok := configFlags[f.Name]
+		if !ok {
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			opts.Flags[key] = strings.Join(slice.GetSlice(), ",")
+		} else {
+			opts.Flags[key] = f.Value.String()
+		}
+	})
+	return opts, nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/history"
+	"github.com/ismailtsdln/DevTestrider/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var ingestCmd = &cobra.Command{
+	Use:   "ingest [file]",
+	Short: "Import a go test -json log as a run",
+	Long: `Import the output of "go test -json", e.g. from CI or another machine, as if
+the tests had run here: the run is printed, saved to the history and its
+reports in report.formats are written. Without a file, or with "-", the log
+is read from stdin:
+
+  go test -json ./... > results.json
+  devtestrider ingest results.json
+
+A log cut short counts the packages it never finished as failed. Failing
+tests do not change the exit status, which only reports whether the log
+could be read.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _ := loadConfig(cmd)
+		out, err := newOutput()
+		if err != nil {
+			return err
+		}
+
+		runner := engine.NewRunner()
+		runner.Progress = progressOf(out)
+		result, err := ingestLog(runner, args)
+		if err != nil {
+			return err
+		}
+
+		out.Result(result)
+		insights := report.Insights{}
+		if cfg.History.Enable {
+			store := history.New(cfg.History.Dir, cfg.History.MaxRuns)
+			if _, err := store.Save(result); err != nil {
+				log.Printf("Error saving run history: %v", err)
+			} else if runs, err := store.Recent(report.HistoryRuns(cfg)); err == nil {
+				insights = report.NewInsights(runs, cfg)
+			}
+		}
+		paths, err := report.Write(cfg, result, insights)
+		for _, path := range paths {
+			out.Info("Report generated:", path)
+		}
+		if err != nil {
+			log.Printf("Failed to generate reports: %v", err)
+		}
+		return nil
+	},
+}
+
+// ingestLog reads the go test -json log named by args, stdin if there is
+// none or it is "-", into a result.
+func ingestLog(runner *engine.Runner, args []string) (*engine.TestResult, error) {
+	var in io.Reader = os.Stdin
+	name := "stdin"
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in, name = f, args[0]
+	}
+	result, err := runner.Ingest(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return result, nil
+}
+
+func init() {
+	rootCmd.AddCommand(ingestCmd)
+}
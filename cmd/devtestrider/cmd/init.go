@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+var initForce bool
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a testrider.yml for the current project",
+	Long: `Inspect the current project (modules, vendor and testdata directories,
+frontend folders) and write a commented testrider.yml with matching watch and
+ignore paths, a report directory and test profiles.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := configOptions(cmd)
+		if err != nil {
+			return err
+		}
+		path := opts.Path
+
+		if _, err := os.Stat(path); err == nil && !initForce {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+		}
+
+		project, err := scaffold.Inspect(".")
+		if err != nil {
+			return err
+		}
+		data, err := scaffold.Render(project)
+		if err != nil {
+			return err
+		}
+		// Never write a file that would not load
+		if _, err := config.Parse(path, data); err != nil {
+			return fmt.Errorf("generated config is invalid: %w", err)
+		}
+
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s (%d modules, watching %s, ignoring %d paths)\n", path, len(project.Modules), strings.Join(project.Paths, ", "), len(project.Ignore))
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite an existing config file")
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/lsp"
+	"github.com/spf13/cobra"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server over stdio for editor integration",
+	Long: `Run a Language Server Protocol server on stdin and stdout.
+
+Editors that start it get failing tests and go vet issues as diagnostics on
+the offending lines, and "run test" and "debug test" code lenses above test
+functions. Tests run once on startup and for the package of every saved file.
+Debugging starts a headless Delve session and needs dlv on the PATH.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := configOptions(cmd)
+		if err != nil {
+			return err
+		}
+		load := func() (*config.Config, error) {
+			cfg, _, err := config.Resolve(opts)
+			return cfg, err
+		}
+		return lsp.New(load).Serve(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+}
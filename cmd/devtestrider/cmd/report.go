@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/ismailtsdln/DevTestrider/internal/console"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var reportFormats []string
+
+var reportCmd = &cobra.Command{
+	Use:   "report [file]",
+	Short: "Write reports of a go test -json log",
+	Long: `Write the reports of a "go test -json" log without running anything or
+touching the run history. Without a file, or with "-", the log is read from
+stdin:
+
+  go test -json ./... | devtestrider report --format html
+
+Here --format selects the report formats, overriding report.formats; the
+reports are written to report.output_dir. Use "ingest" to also save the run
+in the history.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("format") {
+			setOverride = append(setOverride, "report.formats="+strings.Join(reportFormats, ","))
+		}
+		cfg, _ := loadConfig(cmd)
+		out, err := console.New(os.Stderr, console.FormatPlain)
+		if err != nil {
+			return err
+		}
+
+		result, err := ingestLog(engine.NewRunner(), args)
+		if err != nil {
+			return err
+		}
+		paths, err := report.Write(cfg, result, report.Insights{})
+		for _, path := range paths {
+			out.Info("Report generated:", path)
+		}
+		return err
+	},
+}
+
+func init() {
+	// Shadows the terminal output --format: this command only writes reports
+	reportCmd.Flags().StringSliceVar(&reportFormats, "format", nil, "report formats to generate (default report.formats)")
+	rootCmd.AddCommand(reportCmd)
+}
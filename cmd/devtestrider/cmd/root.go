@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
@@ -10,34 +11,35 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/ismailtsdln/DevTestrider/internal/config"
 	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/git"
+	"github.com/ismailtsdln/DevTestrider/internal/history"
 	"github.com/ismailtsdln/DevTestrider/internal/orchestrator"
 	"github.com/ismailtsdln/DevTestrider/internal/server"
+	"github.com/ismailtsdln/DevTestrider/internal/tui"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "devtestrider",
 	Short: "DevTestrider - Real-time Go Test Runner & Dashboard",
+	// Errors are printed once by main
+	SilenceUsage:  true,
+	SilenceErrors: true,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load Config
-		cfgPath := "testrider.yml"
-		if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
-			// If not found, use defaults
-			log.Println("Config file not found, using defaults")
-		}
-
-		cfg, err := config.Load(cfgPath)
+		cfg, opts := loadConfig(cmd)
+		out, err := newOutput()
 		if err != nil {
-			// Fallback to default if error or file missing
-			// For this MVP, let's just create a default config in memory
-			cfg = &config.Config{
-				Watch:  config.WatchConfig{Paths: []string{"."}, Ignore: []string{".git", "node_modules", "vendor"}},
-				Server: config.ServerConfig{Port: 8080},
-			}
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 
 		// Initialize Components
 		runner := engine.NewRunner()
+		runner.Profile = cfg.ActiveProfile()
+		runner.Modules, err = engine.DiscoverModules(".", cfg.Watch.Ignore)
+		if err != nil {
+			log.Printf("Module discovery failed: %v", err)
+		}
 		srv := server.NewServer(cfg.Server)
 
 		watcher, err := engine.NewWatcher(cfg.Watch)
@@ -55,6 +57,29 @@ var rootCmd = &cobra.Command{
 		// Start Watcher
 		go watcher.Start()
 
+		// Start Orchestrator
+		orch := orchestrator.New(cfg, runner, watcher, srv)
+		if cfg.History.Enable {
+			orch.EnableHistory(history.New(cfg.History.Dir, cfg.History.MaxRuns))
+		}
+		quit := make(chan os.Signal, 1)
+		orchestratorDone := make(chan bool)
+
+		var ui *tui.TUI
+		if tuiMode {
+			ui = tui.New(orch, profileNames(cfg), cfg.Profile)
+			orch.SetOutput(ui)
+			runner.Progress = ui.Progress
+			runner.Stderr = ui.Writer()
+			srv.SetLogOutput(io.Discard)
+		} else {
+			orch.SetOutput(out)
+			runner.Progress = progressOf(out)
+			if machineReadable() {
+				srv.SetLogOutput(os.Stderr)
+			}
+		}
+
 		titleStyle := lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("#FAFAFA")).
@@ -63,27 +88,105 @@ var rootCmd = &cobra.Command{
 
 		infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
 
-		fmt.Println(titleStyle.Render("DevTestrider Started"))
-		fmt.Println(infoStyle.Render("Watching for file changes..."))
-		fmt.Printf("Server running at http://localhost:%d\n", cfg.Server.Port)
+		if ui == nil && !machineReadable() {
+			fmt.Println(titleStyle.Render("DevTestrider Started"))
+			fmt.Println(infoStyle.Render("Watching for file changes..."))
+			fmt.Printf("Server running at %s\n", srv.URL())
+		}
 
-		// Start Orchestrator
-		orch := orchestrator.New(cfg, runner, watcher, srv)
-		quit := make(chan os.Signal, 1)
-		orchestratorDone := make(chan bool)
+		// Config Hot Reload
+		if _, err := os.Stat(opts.Path); err == nil {
+			reload := func() (*config.Config, error) {
+				cfg, _, err := config.Resolve(opts)
+				return cfg, err
+			}
+			if err := orch.EnableConfigReload(opts.Path, reload); err != nil {
+				log.Printf("Config reload disabled: %v", err)
+			}
+		}
+
+		// Git Integration
+		var headWatcher *git.HeadWatcher
+		if cfg.Git.Enable && git.IsRepo(".") {
+			headWatcher, err = git.NewHeadWatcher(".", cfg.Git.Interval)
+			if err != nil {
+				log.Printf("Git integration disabled: %v", err)
+			} else {
+				orch.EnableGit(headWatcher)
+				go headWatcher.Start()
+			}
+		}
 
 		go orch.Start(orchestratorDone)
 
 		// Graceful Shutdown
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-		<-quit
+		if ui != nil {
+			go func() {
+				<-quit
+				ui.Quit()
+			}()
+			// The UI only accepts messages once it runs, so nothing on this
+			// goroutine may log through it before Run
+			log.SetOutput(ui.Writer())
+			go ui.Info("Dashboard at", srv.URL())
+			err := ui.Run()
+			log.SetOutput(os.Stderr)
+			if err != nil {
+				log.Printf("Terminal UI failed: %v", err)
+			}
+		} else {
+			<-quit
+		}
 
-		fmt.Println(infoStyle.Render("Shutting down..."))
+		if !machineReadable() {
+			fmt.Println(infoStyle.Render("Shutting down..."))
+		}
 		// orchestratorDone <- true // Optional cleanup
+		runner.Kill()
 		watcher.Stop()
+		if headWatcher != nil {
+			headWatcher.Stop()
+		}
 	},
 }
 
+var tuiMode bool
+
+func init() {
+	rootCmd.Flags().BoolVar(&tuiMode, "tui", false, "show an interactive terminal UI instead of plain output")
+}
+
+const defaultConfigPath = "testrider.yml"
+
+// profileNames lists the configured test profiles.
+func profileNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	return names
+}
+
+// loadConfig resolves the config from defaults, file, environment and flags.
+// Invalid configs are reported and abort the command.
+func loadConfig(cmd *cobra.Command) (*config.Config, config.Options) {
+	opts, err := configOptions(cmd)
+	if err == nil {
+		if _, statErr := os.Stat(opts.Path); os.IsNotExist(statErr) && !opts.Explicit {
+			log.Println("Config file not found, using defaults")
+		}
+		var cfg *config.Config
+		cfg, _, err = config.Resolve(opts)
+		if err == nil {
+			return cfg, opts
+		}
+	}
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+	return nil, opts
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/git"
+	"github.com/ismailtsdln/DevTestrider/internal/history"
+	"github.com/ismailtsdln/DevTestrider/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var runSince string
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the tests once and exit",
+	Long: `Run the tests once and exit with a non-zero status if any fail.
+
+With --since, only packages changed relative to the given ref (measured from
+the merge base, including uncommitted changes and new files that are not
+ignored) are tested, which makes it suitable as a pre-push check:
+
+  devtestrider run --since=origin/main
+
+The reports in report.formats are written as in watch mode. With markdown
+among them, the summary is also appended to $GITHUB_STEP_SUMMARY in GitHub
+Actions.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _ := loadConfig(cmd)
+		out, err := newOutput()
+		if err != nil {
+			return err
+		}
+
+		pkgs := []string{"./..."}
+		if runSince != "" {
+			files, err := git.ChangedSince(".", runSince)
+			if err != nil {
+				return err
+			}
+			pkgs = engine.PackagesForFiles(files, cfg.Watch.Ignore)
+			if len(pkgs) == 0 {
+				out.Info("No Go packages changed since", runSince)
+				return nil
+			}
+		}
+
+		runner := engine.NewRunner()
+		modules, err := engine.DiscoverModules(".", cfg.Watch.Ignore)
+		if err != nil {
+			return err
+		}
+		runner.Modules = modules
+		runner.Profile = cfg.ActiveProfile()
+		runner.Progress = progressOf(out)
+
+		// Test binaries run in their own process group and miss the
+		// terminal's interrupt, so pass it on
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-interrupt
+			runner.Kill()
+			os.Exit(1)
+		}()
+
+		result, err := runner.RunPackages(pkgs...)
+		if err != nil {
+			return err
+		}
+		if issues, err := runner.Vet(pkgs...); err == nil {
+			result.Issues = issues
+		}
+
+		out.Result(result)
+		insights := report.Insights{}
+		if cfg.History.Enable {
+			store := history.New(cfg.History.Dir, cfg.History.MaxRuns)
+			if _, err := store.Save(result); err != nil {
+				log.Printf("Error saving run history: %v", err)
+			} else if runs, err := store.Recent(report.HistoryRuns(cfg)); err == nil {
+				insights = report.NewInsights(runs, cfg)
+			}
+		}
+		paths, err := report.Write(cfg, result, insights)
+		for _, path := range paths {
+			out.Info("Report generated:", path)
+		}
+		if err != nil {
+			log.Printf("Failed to generate reports: %v", err)
+		}
+		if !result.Success {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	runCmd.Flags().StringVar(&runSince, "since", "", "only test packages changed relative to this git ref")
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,146 @@
+// Package analytics derives trends from the run history, such as test
+// duration baselines and regressions.
+package analytics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// A test needs this many earlier timings before it can be flagged as a
+// regression.
+const minSamples = 3
+
+// SlowReport holds the duration baselines of tests and packages and what
+// stands out in the latest run.
+type SlowReport struct {
+	// Runs is how many earlier runs the baselines are computed from.
+	Runs             int     `json:"runs"`
+	RegressionFactor float64 `json:"regression_factor"`
+	// Slowest are the slowest tests of the latest run, slowest first.
+	Slowest []Timing `json:"slowest"`
+	// Regressions are the tests of the latest run slower than
+	// RegressionFactor times their median, the worst first.
+	Regressions []Timing `json:"regressions"`
+	// Packages are the latest run's packages, slowest first.
+	Packages []Timing `json:"packages"`
+}
+
+// Timing is the duration of a test, or of a whole package when Test is
+// empty, in the latest run against its baseline. Durations are in seconds.
+type Timing struct {
+	Package  string  `json:"package"`
+	Test     string  `json:"test,omitempty"`
+	Duration float64 `json:"duration"`
+	// Median and P95 are over the earlier runs that had the test; Samples
+	// counts them. Both are 0 without samples.
+	Median  float64 `json:"median"`
+	P95     float64 `json:"p95"`
+	Samples int     `json:"samples"`
+	// Ratio is Duration over Median, or 0 without samples.
+	Ratio float64 `json:"ratio"`
+}
+
+// Slow analyses the last of runs (oldest first) against the earlier ones.
+// Only leaf tests are timed, since a parent's duration is its subtests'.
+// It returns nil if there are no runs.
+func Slow(runs []*engine.TestResult, cfg config.AnalyticsConfig) *SlowReport {
+	if len(runs) == 0 {
+		return nil
+	}
+	latest, earlier := runs[len(runs)-1], runs[:len(runs)-1]
+	if cfg.BaselineRuns > 0 && len(earlier) > cfg.BaselineRuns {
+		earlier = earlier[len(earlier)-cfg.BaselineRuns:]
+	}
+
+	tests := make(map[string][]float64)
+	packages := make(map[string][]float64)
+	for _, run := range earlier {
+		for name, pkg := range run.Packages {
+			if timedPackage(pkg) {
+				packages[name] = append(packages[name], pkg.Duration)
+			}
+			for _, test := range timed(pkg) {
+				key := name + " " + test.Name
+				tests[key] = append(tests[key], test.Duration)
+			}
+		}
+	}
+
+	report := &SlowReport{Runs: len(earlier), RegressionFactor: cfg.RegressionFactor, Regressions: []Timing{}, Packages: []Timing{}}
+	timings := []Timing{}
+	for name, pkg := range latest.Packages {
+		if timedPackage(pkg) {
+			report.Packages = append(report.Packages, timing(name, "", pkg.Duration, packages[name]))
+		}
+		for _, test := range timed(pkg) {
+			t := timing(name, test.Name, test.Duration, tests[name+" "+test.Name])
+			timings = append(timings, t)
+			if t.Samples >= minSamples && t.Duration >= cfg.MinDuration.Seconds() && t.Ratio > cfg.RegressionFactor {
+				report.Regressions = append(report.Regressions, t)
+			}
+		}
+	}
+
+	sortBy(report.Packages, func(t Timing) float64 { return t.Duration })
+	sortBy(timings, func(t Timing) float64 { return t.Duration })
+	sortBy(report.Regressions, func(t Timing) float64 { return t.Ratio })
+	report.Slowest = timings[:min(len(timings), cfg.Slowest)]
+	return report
+}
+
+// timedPackage reports whether the duration of pkg means something: it ran
+// its tests to the end.
+func timedPackage(pkg *engine.PackageResult) bool {
+	return pkg.Status == "PASS" || pkg.Status == "FAIL"
+}
+
+// timed are the tests of pkg whose duration means something: finished leaf
+// tests.
+func timed(pkg *engine.PackageResult) []*engine.TestCase {
+	var tests []*engine.TestCase
+	for _, test := range pkg.Leaves() {
+		if test.Status == "PASS" || test.Status == "FAIL" {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+func timing(pkg, test string, duration float64, samples []float64) Timing {
+	t := Timing{Package: pkg, Test: test, Duration: duration, Samples: len(samples)}
+	if len(samples) == 0 {
+		return t
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	t.Median = percentile(sorted, 50)
+	t.P95 = percentile(sorted, 95)
+	if t.Median > 0 {
+		t.Ratio = duration / t.Median
+	}
+	return t
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// sortBy orders timings by key, largest first, then by package and test.
+func sortBy(timings []Timing, key func(Timing) float64) {
+	sort.Slice(timings, func(i, j int) bool {
+		a, b := timings[i], timings[j]
+		if key(a) != key(b) {
+			return key(a) > key(b)
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Test < b.Test
+	})
+}
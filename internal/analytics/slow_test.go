@@ -0,0 +1,128 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{[]float64{7}, 50, 7},
+		{[]float64{7}, 95, 7},
+		{[]float64{1, 2}, 50, 1},
+		{[]float64{1, 2}, 95, 2},
+		{[]float64{1, 2, 3, 4, 5}, 50, 3},
+		{[]float64{1, 2, 3, 4, 5}, 95, 5},
+		{[]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, 95, 19},
+		{[]float64{1, 2, 3}, 0, 1},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %v) = %v, want %v", tt.sorted, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestTiming(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		samples  []float64
+		want     Timing
+	}{
+		{"no samples", 2, nil, Timing{Package: "p", Test: "T", Duration: 2}},
+		{"unsorted samples", 4, []float64{3, 1, 2}, Timing{Package: "p", Test: "T", Duration: 4, Median: 2, P95: 3, Samples: 3, Ratio: 2}},
+		{"zero median", 1, []float64{0, 0}, Timing{Package: "p", Test: "T", Duration: 1, Samples: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timing("p", "T", tt.duration, tt.samples); got != tt.want {
+				t.Errorf("timing = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testRuns is one run per duration of a package p with a single test TestA
+// with that status, the last run being the latest.
+func testRuns(status string, durations ...float64) []*engine.TestResult {
+	var runs []*engine.TestResult
+	for i, d := range durations {
+		s := "PASS"
+		if i == len(durations)-1 {
+			s = status
+		}
+		runs = append(runs, runOf(i, pkgResult("p", d, 0, s)))
+	}
+	return runs
+}
+
+func TestSlowRegressions(t *testing.T) {
+	cfg := config.AnalyticsConfig{RegressionFactor: 2, MinDuration: 100 * time.Millisecond, Slowest: 10}
+	tests := []struct {
+		name      string
+		cfg       config.AnalyticsConfig
+		runs      []*engine.TestResult
+		wantRatio float64 // 0 for no regression
+	}{
+		{"slower than the factor", cfg, testRuns("PASS", 1, 1, 1, 3), 3},
+		{"failing tests are timed", cfg, testRuns("FAIL", 1, 1, 1, 3), 3},
+		{"too few samples", cfg, testRuns("PASS", 1, 1, 3), 0},
+		{"shorter than min_duration", cfg, testRuns("PASS", 0.01, 0.01, 0.01, 0.05), 0},
+		{"exactly the factor", cfg, testRuns("PASS", 1, 1, 1, 2), 0},
+		{"custom factor", config.AnalyticsConfig{RegressionFactor: 1.5, MinDuration: cfg.MinDuration}, testRuns("PASS", 1, 1, 1, 2), 2},
+		{"all earlier runs", cfg, testRuns("PASS", 1, 1, 1, 3, 3, 3, 5), 5},
+		{"last baseline_runs only", config.AnalyticsConfig{RegressionFactor: 2, MinDuration: cfg.MinDuration, BaselineRuns: 3}, testRuns("PASS", 1, 1, 1, 3, 3, 3, 5), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Slow(tt.runs, tt.cfg)
+			if tt.wantRatio == 0 {
+				if len(report.Regressions) != 0 {
+					t.Errorf("regressions = %+v, want none", report.Regressions)
+				}
+				return
+			}
+			if len(report.Regressions) != 1 || report.Regressions[0].Ratio != tt.wantRatio {
+				t.Errorf("regressions = %+v, want TestA at %vx", report.Regressions, tt.wantRatio)
+			}
+		})
+	}
+
+	if report := Slow(testRuns("SKIP", 1, 1, 1, 3), cfg); len(report.Slowest) != 0 || len(report.Regressions) != 0 {
+		t.Errorf("skipped test timed: %+v", report)
+	}
+	if report := Slow(nil, cfg); report != nil {
+		t.Errorf("Slow of no runs = %+v, want nil", report)
+	}
+}
+
+func TestSlowLeaderboard(t *testing.T) {
+	runs := []*engine.TestResult{
+		runOf(0, pkgResult("a", 3, 0, "PASS", "PASS", "PASS"), pkgResult("b", 4, 0, "PASS", "PASS")),
+	}
+	report := Slow(runs, config.AnalyticsConfig{Slowest: 3})
+	want := []Timing{
+		{Package: "b", Test: "TestA", Duration: 2},
+		{Package: "b", Test: "TestB", Duration: 2},
+		{Package: "a", Test: "TestA", Duration: 1},
+	}
+	if len(report.Slowest) != len(want) {
+		t.Fatalf("slowest = %+v, want %+v", report.Slowest, want)
+	}
+	for i := range want {
+		if report.Slowest[i] != want[i] {
+			t.Errorf("slowest[%d] = %+v, want %+v", i, report.Slowest[i], want[i])
+		}
+	}
+	if len(report.Packages) != 2 || report.Packages[0].Package != "b" {
+		t.Errorf("packages = %+v, want b first", report.Packages)
+	}
+}
@@ -0,0 +1,282 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Point summarises the suite as of one run for trend charts. Packages the
+// run did not test count with their last result, so partial watch-mode runs
+// chart like full ones.
+type Point struct {
+	Timestamp time.Time `json:"timestamp"`
+	// PassRate is the percentage of passed among passed and failed leaf
+	// tests, 100 for a run without any.
+	PassRate float64 `json:"pass_rate"`
+	Tests    int     `json:"tests"`
+	// Coverage is the mean coverage of the packages that report one.
+	Coverage float64 `json:"coverage"`
+	// Duration is the sum of the packages' durations.
+	Duration float64 `json:"duration"`
+}
+
+// Trend summarises runs, oldest first, keeping their order.
+func Trend(runs []*engine.TestResult) []Point {
+	points := make([]Point, len(runs))
+	for i := range runs {
+		suite := Snapshot(runs[:i+1])
+		p := Point{Timestamp: suite.Timestamp, PassRate: 100, Tests: suite.TotalTests, Duration: suite.Duration}
+		if ran := suite.PassedTests + suite.FailedTests; ran > 0 {
+			p.PassRate = float64(suite.PassedTests) / float64(ran) * 100
+		}
+		p.Coverage = meanCoverage(suite.Packages)
+		points[i] = p
+	}
+	return points
+}
+
+// Snapshot merges runs, oldest first, into the last result of every package
+// as of the last run, so that partial watch-mode runs can be compared like
+// full ones. Its counts and duration are those of the merged packages; its
+// timestamp and vet issues are the last run's. A package deleted since it
+// last ran is still carried forward, so comparing snapshots never reports it
+// removed.
+func Snapshot(runs []*engine.TestResult) *engine.TestResult {
+	suite := &engine.TestResult{Packages: make(map[string]*engine.PackageResult), Success: true}
+	for _, run := range runs {
+		for name, pkg := range run.Packages {
+			suite.Packages[name] = pkg
+		}
+		suite.Timestamp, suite.Issues = run.Timestamp, run.Issues
+	}
+	for _, pkg := range suite.Packages {
+		suite.Duration += pkg.Duration
+		if pkg.Failed() {
+			suite.Success = false
+		}
+		for _, test := range pkg.AllTests() {
+			if !test.Leaf() {
+				suite.ParentTests++
+				continue
+			}
+			suite.TotalTests++
+			switch test.Status {
+			case "PASS":
+				suite.PassedTests++
+			case "FAIL":
+				suite.FailedTests++
+			case "SKIP":
+				suite.SkippedTests++
+			}
+		}
+	}
+	return suite
+}
+
+func meanCoverage(packages map[string]*engine.PackageResult) float64 {
+	sum, n := 0.0, 0
+	for _, pkg := range packages {
+		if pkg.Coverage > 0 {
+			sum += pkg.Coverage
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// Durations that changed by less than this many seconds are noise and not
+// listed in a Comparison.
+const durationNoise = 0.1
+
+// Comparison is what changed from one run to another.
+type Comparison struct {
+	// Coverage lists the packages of both runs that report coverage in
+	// either.
+	Coverage     []CoverageDelta `json:"coverage"`
+	NewlyFailing []TestRef       `json:"newly_failing"`
+	NewlyPassing []TestRef       `json:"newly_passing"`
+	// Added and Removed are the tests of the packages in both runs that
+	// ran in only one of them.
+	Added   []TestRef `json:"added"`
+	Removed []TestRef `json:"removed"`
+	// AddedPackages and RemovedPackages ran in only one of the runs.
+	AddedPackages   []string `json:"added_packages"`
+	RemovedPackages []string `json:"removed_packages"`
+	// Duration compares the runs as a whole.
+	Duration DurationDelta `json:"duration"`
+	// Durations lists the packages and leaf tests of both runs whose
+	// duration changed noticeably, the largest change first.
+	Durations []DurationDelta `json:"durations"`
+}
+
+// CoverageDelta is a package's coverage in two runs, in percent.
+type CoverageDelta struct {
+	Package  string  `json:"package"`
+	Previous float64 `json:"previous"`
+	Current  float64 `json:"current"`
+	Delta    float64 `json:"delta"`
+}
+
+// DurationDelta is the duration of a package, or of a test when Test is
+// set, in two runs, in seconds.
+type DurationDelta struct {
+	Package  string  `json:"package,omitempty"`
+	Test     string  `json:"test,omitempty"`
+	Previous float64 `json:"previous"`
+	Current  float64 `json:"current"`
+	Delta    float64 `json:"delta"`
+}
+
+// TestRef names a test; an empty Test stands for a package that failed
+// without a failing test, e.g. one that did not build.
+type TestRef struct {
+	Package string `json:"package"`
+	Test    string `json:"test,omitempty"`
+}
+
+// Compare compares cur with prev package by package. Apart from being
+// listed as added or removed, packages missing from either run are left
+// out.
+func Compare(prev, cur *engine.TestResult) *Comparison {
+	c := &Comparison{
+		Coverage:        []CoverageDelta{},
+		NewlyFailing:    []TestRef{},
+		NewlyPassing:    []TestRef{},
+		Added:           []TestRef{},
+		Removed:         []TestRef{},
+		AddedPackages:   []string{},
+		RemovedPackages: []string{},
+		Duration:        DurationDelta{Previous: prev.Duration, Current: cur.Duration, Delta: cur.Duration - prev.Duration},
+		Durations:       []DurationDelta{},
+	}
+	for _, name := range packageNames(prev) {
+		if cur.Packages[name] == nil {
+			c.RemovedPackages = append(c.RemovedPackages, name)
+		}
+	}
+	for _, name := range packageNames(cur) {
+		now, before := cur.Packages[name], prev.Packages[name]
+		if before == nil {
+			c.AddedPackages = append(c.AddedPackages, name)
+			continue
+		}
+		if now.Coverage > 0 || before.Coverage > 0 {
+			c.Coverage = append(c.Coverage, CoverageDelta{
+				Package:  name,
+				Previous: before.Coverage,
+				Current:  now.Coverage,
+				Delta:    now.Coverage - before.Coverage,
+			})
+		}
+		c.NewlyFailing = append(c.NewlyFailing, newFailures(before, now)...)
+		c.NewlyPassing = append(c.NewlyPassing, newFailures(now, before)...)
+		c.Added = append(c.Added, onlyIn(now, before)...)
+		c.Removed = append(c.Removed, onlyIn(before, now)...)
+		c.Durations = append(c.Durations, durationChanges(before, now)...)
+	}
+	sort.SliceStable(c.Durations, func(i, j int) bool {
+		return math.Abs(c.Durations[i].Delta) > math.Abs(c.Durations[j].Delta)
+	})
+	return c
+}
+
+// onlyIn lists the tests of pkg that other does not have.
+func onlyIn(pkg, other *engine.PackageResult) []TestRef {
+	tests := make(map[string]bool)
+	for _, test := range other.AllTests() {
+		tests[test.Name] = true
+	}
+	var refs []TestRef
+	for _, test := range pkg.AllTests() {
+		if !tests[test.Name] {
+			refs = append(refs, TestRef{Package: pkg.Name, Test: test.Name})
+		}
+	}
+	return refs
+}
+
+// durationChanges compares the duration of a package and its timed tests
+// in two runs, leaving out changes below durationNoise.
+func durationChanges(before, now *engine.PackageResult) []DurationDelta {
+	var deltas []DurationDelta
+	add := func(test string, prev, cur float64) {
+		if math.Abs(cur-prev) >= durationNoise {
+			deltas = append(deltas, DurationDelta{Package: now.Name, Test: test, Previous: prev, Current: cur, Delta: cur - prev})
+		}
+	}
+	if timedPackage(before) && timedPackage(now) {
+		add("", before.Duration, now.Duration)
+	}
+	tests := make(map[string]float64)
+	for _, test := range timed(before) {
+		tests[test.Name] = test.Duration
+	}
+	for _, test := range timed(now) {
+		if prev, ok := tests[test.Name]; ok {
+			add(test.Name, prev, test.Duration)
+		}
+	}
+	return deltas
+}
+
+// newFailures lists what fails in now but did not in before. Read the other
+// way round, it lists what was fixed.
+func newFailures(before, now *engine.PackageResult) []TestRef {
+	var refs []TestRef
+	failures := now.Failures()
+	if now.Failed() && len(failures) == 0 && !before.Failed() {
+		refs = append(refs, TestRef{Package: now.Name})
+	}
+	tests := make(map[string]*engine.TestCase)
+	for _, test := range before.AllTests() {
+		tests[test.Name] = test
+	}
+	for _, test := range failures {
+		// A test that did not run before is new, not newly failing; one
+		// that stopped running or was skipped is not fixed
+		if old, ok := tests[test.Name]; ok && old.Status == "PASS" {
+			refs = append(refs, TestRef{Package: now.Name, Test: test.Name})
+		}
+	}
+	return refs
+}
+
+// Previous returns, for the packages of cur, the result each had the last
+// time it ran in runs (oldest first), so that partial runs are compared
+// with the last run of every package they tested. It returns nil if none
+// of cur's packages ran before.
+func Previous(runs []*engine.TestResult, cur *engine.TestResult) *engine.TestResult {
+	prev := &engine.TestResult{Packages: make(map[string]*engine.PackageResult)}
+	for i := len(runs) - 1; i >= 0; i-- {
+		for name := range cur.Packages {
+			if _, done := prev.Packages[name]; done {
+				continue
+			}
+			if pkg, ok := runs[i].Packages[name]; ok {
+				prev.Packages[name] = pkg
+				if prev.Timestamp.IsZero() {
+					prev.Timestamp = runs[i].Timestamp
+				}
+			}
+		}
+	}
+	if len(prev.Packages) == 0 {
+		return nil
+	}
+	return prev
+}
+
+func packageNames(result *engine.TestResult) []string {
+	names := make([]string, 0, len(result.Packages))
+	for name := range result.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// pkgResult is a package with one test per status given, named after its
+// index.
+func pkgResult(name string, duration, coverage float64, statuses ...string) *engine.PackageResult {
+	pkg := &engine.PackageResult{Name: name, Status: "PASS", Duration: duration, Coverage: coverage}
+	for i, status := range statuses {
+		pkg.Tests = append(pkg.Tests, &engine.TestCase{Name: "Test" + string(rune('A'+i)), Status: status, Duration: duration / float64(len(statuses))})
+		if status == "FAIL" {
+			pkg.Status = "FAIL"
+		}
+	}
+	return pkg
+}
+
+func runOf(at int, packages ...*engine.PackageResult) *engine.TestResult {
+	result := &engine.TestResult{
+		Timestamp: time.Date(2026, 10, 19, 12, at, 0, 0, time.UTC),
+		Packages:  make(map[string]*engine.PackageResult),
+		Success:   true,
+	}
+	for _, pkg := range packages {
+		result.Packages[pkg.Name] = pkg
+		result.Duration += pkg.Duration
+		if pkg.Failed() {
+			result.Success = false
+		}
+	}
+	return result
+}
+
+func TestTrendCarriesPackagesForward(t *testing.T) {
+	points := Trend([]*engine.TestResult{
+		runOf(0, pkgResult("a", 1, 80, "PASS", "PASS"), pkgResult("b", 3, 40, "PASS", "PASS")),
+		// A watch-mode run of b only
+		runOf(1, pkgResult("b", 2, 60, "PASS", "FAIL")),
+	})
+	want := []Point{
+		{Timestamp: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC), PassRate: 100, Tests: 4, Coverage: 60, Duration: 4},
+		{Timestamp: time.Date(2026, 10, 19, 12, 1, 0, 0, time.UTC), PassRate: 75, Tests: 4, Coverage: 70, Duration: 3},
+	}
+	for i := range want {
+		if points[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, points[i], want[i])
+		}
+	}
+}
+
+func TestCompareSnapshotsOfPartialRuns(t *testing.T) {
+	runs := []*engine.TestResult{
+		runOf(0, pkgResult("a", 1, 80, "PASS"), pkgResult("b", 2, 40, "PASS")),
+		runOf(1, pkgResult("a", 1, 80, "PASS")),
+		// A watch-mode run of b only
+		runOf(2, pkgResult("b", 4, 45, "FAIL")),
+	}
+	diff := Compare(Snapshot(runs[:2]), Snapshot(runs))
+
+	if len(diff.AddedPackages) != 0 || len(diff.RemovedPackages) != 0 {
+		t.Errorf("packages added %v, removed %v, want none: untested packages are carried forward", diff.AddedPackages, diff.RemovedPackages)
+	}
+	if len(diff.NewlyFailing) != 1 || diff.NewlyFailing[0].Package != "b" || diff.NewlyFailing[0].Test != "TestA" {
+		t.Errorf("newly failing = %+v, want b TestA", diff.NewlyFailing)
+	}
+	if len(diff.Coverage) != 2 || diff.Coverage[1].Package != "b" || diff.Coverage[1].Delta != 5 {
+		t.Errorf("coverage = %+v, want b up 5", diff.Coverage)
+	}
+	if diff.Duration.Previous != 3 || diff.Duration.Current != 5 {
+		t.Errorf("duration = %+v, want 3s to 5s with a counted in both", diff.Duration)
+	}
+}
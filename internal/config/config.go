@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"reflect"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,35 +14,287 @@ type Config struct {
 	Report        ReportConfig        `yaml:"report"`
 	Notifications NotificationsConfig `yaml:"notifications"`
 	Server        ServerConfig        `yaml:"server"`
+	Git           GitConfig           `yaml:"git"`
+	History       HistoryConfig       `yaml:"history"`
+	Analytics     AnalyticsConfig     `yaml:"analytics"`
+	// Profile names the entry of Profiles used for test runs, if any.
+	Profile  string             `yaml:"profile"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is a named set of "go test" options, e.g. a fast profile for
+// every save and a thorough one with the race detector.
+type Profile struct {
+	Tags  []string `yaml:"tags"`
+	Run   string   `yaml:"run"`
+	Race  bool     `yaml:"race"`
+	Short bool     `yaml:"short"`
+	Args  []string `yaml:"args"`
+	// Timeout bounds how long a package's tests may run before the test
+	// binary is stopped and its goroutines dumped; PackageTimeouts overrides
+	// it for packages matching a pattern.
+	Timeout         time.Duration            `yaml:"timeout"`
+	PackageTimeouts map[string]time.Duration `yaml:"package_timeouts"`
+}
+
+// ActiveProfile returns the selected profile, or an empty one if none is.
+func (c *Config) ActiveProfile() Profile {
+	return c.Profiles[c.Profile]
 }
 
 type WatchConfig struct {
 	Paths  []string `yaml:"paths"`
 	Ignore []string `yaml:"ignore"`
+	// Backend selects how changes are detected: "fsnotify", "polling" or
+	// "auto" (polling on network and shared filesystems, fsnotify elsewhere).
+	Backend      string        `yaml:"backend"`
+	PollInterval time.Duration `yaml:"poll_interval"`
 }
 
 type ReportConfig struct {
 	Formats   []string `yaml:"formats"`
 	OutputDir string   `yaml:"output_dir"`
+	// Name is a Go text/template for the file name of a run's reports,
+	// without the extension. See report.NameData for its fields.
+	Name      string          `yaml:"name"`
+	Retention RetentionConfig `yaml:"retention"`
+	Markdown  MarkdownConfig  `yaml:"markdown"`
+	// TrendRuns is how many runs, the current one included, the HTML
+	// report charts from the history; 0 leaves the trends and the changes
+	// since the previous run out.
+	TrendRuns int `yaml:"trend_runs"`
+}
+
+// RetentionConfig limits the reports kept in the output directory. A run's
+// reports in all formats count as one. Zero values disable a limit.
+type RetentionConfig struct {
+	// Keep is how many of the latest runs' reports are kept.
+	Keep   int           `yaml:"keep"`
+	MaxAge time.Duration `yaml:"max_age"`
+	// MaxSizeMB bounds the total size of the reports in megabytes. The
+	// latest run's reports are kept even if they alone exceed it.
+	MaxSizeMB int `yaml:"max_size_mb"`
+}
+
+// MarkdownConfig tunes the Markdown summary meant for pull request comments
+// and CI step summaries.
+type MarkdownConfig struct {
+	// Baseline is a run to compare coverage with, as saved in the history
+	// or written by --format json, e.g. from the main branch.
+	Baseline string `yaml:"baseline"`
+	// OutputLines is how much of a failing test's output is shown, from
+	// the end; 0 shows all of it.
+	OutputLines int `yaml:"output_lines"`
+	// StepSummary also appends the summary to $GITHUB_STEP_SUMMARY when it
+	// is set, as in GitHub Actions.
+	StepSummary bool `yaml:"step_summary"`
 }
 
 type NotificationsConfig struct {
-	Enable   bool     `yaml:"enable"`
-	Channels []string `yaml:"channels"`
+	Enable   bool          `yaml:"enable"`
+	Channels []string      `yaml:"channels"`
+	Slack    SlackConfig   `yaml:"slack"`
+	Webhook  WebhookConfig `yaml:"webhook"`
+	Email    EmailConfig   `yaml:"email"`
+	Rules    RulesConfig   `yaml:"rules"`
+}
+
+// RulesConfig decides which runs are worth a notification.
+type RulesConfig struct {
+	// When lists the conditions that trigger a notification; any one is
+	// enough: always, failure, transition (pass→fail or fail→pass),
+	// new_failures, coverage_drop.
+	When []string `yaml:"when"`
+	// CoverageDrop is the drop in percentage points of any package's
+	// coverage that counts as coverage_drop.
+	CoverageDrop float64 `yaml:"coverage_drop"`
+	// Packages restricts rule evaluation to matching import paths; a
+	// trailing "/..." matches a whole subtree and globs are allowed.
+	Packages   []string         `yaml:"packages"`
+	QuietHours QuietHoursConfig `yaml:"quiet_hours"`
+	// RateLimit is the minimum time between two notifications on the same
+	// channel; ChannelRateLimits overrides it per channel.
+	RateLimit         time.Duration            `yaml:"rate_limit"`
+	ChannelRateLimits map[string]time.Duration `yaml:"channel_rate_limits"`
+}
+
+// QuietHoursConfig suppresses notifications between Start and End, given as
+// local "HH:MM" times. The window may wrap around midnight.
+type QuietHoursConfig struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+}
+
+// SlackConfig configures the "slack" channel, which posts a Block Kit
+// summary to an incoming webhook.
+type SlackConfig struct {
+	WebhookURL string        `yaml:"webhook_url"`
+	Timeout    time.Duration `yaml:"timeout"`
+	Retries    int           `yaml:"retries"`
+}
+
+// WebhookConfig configures the "webhook" channel, which sends a JSON summary
+// (or the rendered Template, a Go text/template) to any HTTP endpoint.
+type WebhookConfig struct {
+	URL      string            `yaml:"url"`
+	Method   string            `yaml:"method"`
+	Headers  map[string]string `yaml:"headers"`
+	Template string            `yaml:"template"`
+	Timeout  time.Duration     `yaml:"timeout"`
+	Retries  int               `yaml:"retries"`
+}
+
+// EmailConfig configures the "email" channel, sent over SMTP. STARTTLS is
+// used whenever the server offers it.
+type EmailConfig struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"password"`
+	From     string        `yaml:"from"`
+	To       []string      `yaml:"to"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Retries  int           `yaml:"retries"`
+}
+
+// GitConfig controls reacting to HEAD moves (commit, checkout, pull,
+// rebase) by testing only the packages touched between the two commits.
+type GitConfig struct {
+	Enable   bool          `yaml:"enable"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// HistoryConfig controls keeping finished runs on disk, one JSON file per
+// run, for analytics across runs.
+type HistoryConfig struct {
+	Enable bool   `yaml:"enable"`
+	Dir    string `yaml:"dir"`
+	// MaxRuns is how many runs are kept; older ones are deleted. 0 keeps
+	// all of them.
+	MaxRuns int `yaml:"max_runs"`
+}
+
+// AnalyticsConfig tunes the slow-test analytics computed from the history.
+type AnalyticsConfig struct {
+	// BaselineRuns is how many past runs test duration baselines are
+	// computed from.
+	BaselineRuns int `yaml:"baseline_runs"`
+	// RegressionFactor flags a test whose duration exceeds its median by
+	// this factor.
+	RegressionFactor float64 `yaml:"regression_factor"`
+	// MinDuration ignores faster tests for regressions, their timings
+	// being mostly noise.
+	MinDuration time.Duration `yaml:"min_duration"`
+	// Slowest is the length of the slowest tests leaderboard.
+	Slowest int `yaml:"slowest"`
 }
 
 type ServerConfig struct {
 	Port int `yaml:"port"`
 }
 
+// Default returns the configuration used for every field the config file
+// does not set.
+func Default() *Config {
+	return &Config{
+		Watch: WatchConfig{
+			Paths:        []string{"."},
+			Ignore:       []string{".git", "node_modules", "vendor"},
+			Backend:      "auto",
+			PollInterval: time.Second,
+		},
+		Report: ReportConfig{
+			OutputDir: "./reports",
+			Name:      "report-{{.Time}}",
+			Retention: RetentionConfig{Keep: 50},
+			Markdown:  MarkdownConfig{OutputLines: 20, StepSummary: true},
+			TrendRuns: 20,
+		},
+		Notifications: NotificationsConfig{
+			Channels: []string{"desktop"},
+			Slack:    SlackConfig{Timeout: 10 * time.Second, Retries: 2},
+			Webhook:  WebhookConfig{Method: "POST", Timeout: 10 * time.Second, Retries: 2},
+			Email:    EmailConfig{Port: 587, Timeout: 30 * time.Second, Retries: 1},
+			Rules:    RulesConfig{When: []string{"transition", "new_failures"}, CoverageDrop: 1},
+		},
+		Server: ServerConfig{Port: 8080},
+		Git: GitConfig{
+			Enable:   true,
+			Interval: 2 * time.Second,
+		},
+		History: HistoryConfig{
+			Enable:  true,
+			Dir:     ".devtestrider/history",
+			MaxRuns: 200,
+		},
+		Analytics: AnalyticsConfig{
+			BaselineRuns:     20,
+			RegressionFactor: 2,
+			MinDuration:      100 * time.Millisecond,
+			Slowest:          10,
+		},
+	}
+}
+
+// Load reads the config file at path and merges it over the defaults. The
+// returned error is a *ValidationError when the file parses but contains
+// unknown keys or invalid values.
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	return Parse(path, data)
+}
+
+// Parse is like Load for config data that has already been read; name is
+// only used in error messages.
+func Parse(name string, data []byte) (*Config, error) {
+	cfg, positions, errs, err := decode(name, data)
+	if err != nil {
 		return nil, err
 	}
-	return &cfg, nil
+
+	if cfg != nil {
+		for _, fe := range cfg.validate() {
+			if node, ok := positions[fe.Path]; ok {
+				fe.Line, fe.Column = node.Line, node.Column
+			}
+			errs = append(errs, fe)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{File: name, Errors: errs}
+	}
+	return cfg, nil
+}
+
+// decode merges the file over the defaults without validating values. It
+// returns unknown keys and type errors as FieldErrors (the config is nil if
+// there are type errors), the value node of every key path set in the file,
+// and an error only if the file is not valid YAML.
+func decode(name string, data []byte) (*Config, map[string]*yaml.Node, []FieldError, error) {
+	cfg := Default()
+	positions := make(map[string]*yaml.Node)
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(root.Content) == 0 {
+		// Empty file
+		return cfg, positions, nil, nil
+	}
+	doc := root.Content[0]
+	collectPositions(doc, "", positions)
+
+	errs := unknownKeys(doc, reflect.TypeOf(Config{}), "")
+	if err := doc.Decode(cfg); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return nil, positions, append(errs, fromTypeError(typeErr, positions)...), nil
+	}
+	return cfg, positions, errs, nil
 }
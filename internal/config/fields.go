@@ -0,0 +1,74 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// yamlName returns the key a struct field is read from, or "" if the field
+// is not part of the file format.
+func yamlName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// fieldByName finds the struct field decoded from the given YAML key.
+func fieldByName(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == key {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// walkLeaves calls fn for every non-struct value reachable from v, keyed by
+// its dotted YAML path (e.g. "watch.poll_interval"). Map entries use their
+// key as a path segment.
+func walkLeaves(v reflect.Value, prefix string, fn func(path string, v reflect.Value)) {
+	switch {
+	case v.Kind() == reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if name := yamlName(t.Field(i)); name != "" {
+				walkLeaves(v.Field(i), joinPath(prefix, name), fn)
+			}
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && isStruct(v.Type().Elem()):
+		for _, key := range v.MapKeys() {
+			walkLeaves(v.MapIndex(key), joinPath(prefix, key.String()), fn)
+		}
+	case v.Kind() == reflect.Pointer && isStruct(v.Type().Elem()):
+		if !v.IsNil() {
+			walkLeaves(v.Elem(), prefix, fn)
+		}
+	default:
+		fn(prefix, v)
+	}
+}
+
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != durationType
+}
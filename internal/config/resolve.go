@@ -0,0 +1,281 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a
+// config field, e.g. DEVTESTRIDER_SERVER_PORT for server.port.
+const EnvPrefix = "DEVTESTRIDER_"
+
+// Where a config value came from, from lowest to highest precedence.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Options describes the layers merged by Resolve.
+type Options struct {
+	// Path is the config file. A missing file is only an error if Explicit
+	// is set, i.e. the user asked for this particular file.
+	Path     string
+	Explicit bool
+	// Environ is the environment to read overrides from, usually os.Environ().
+	Environ []string
+	// Flags maps dotted key paths (e.g. "server.port") to command line values.
+	// Lists are comma-separated.
+	Flags map[string]string
+}
+
+// Sources maps every leaf key path to the layer that set its value.
+type Sources map[string]string
+
+// Resolve merges, from lowest to highest precedence, the defaults, the config
+// file, DEVTESTRIDER_* environment variables and command line flags, and then
+// validates the result.
+func Resolve(opts Options) (*Config, Sources, error) {
+	cfg := Default()
+	sources := make(Sources)
+	positions := make(map[string]*yaml.Node)
+
+	data, err := os.ReadFile(opts.Path)
+	switch {
+	case err == nil:
+		var errs []FieldError
+		cfg, positions, errs, err = decode(opts.Path, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg == nil || len(errs) > 0 {
+			return nil, nil, &ValidationError{File: opts.Path, Errors: errs}
+		}
+	case os.IsNotExist(err) && !opts.Explicit:
+		// Defaults only
+	default:
+		return nil, nil, err
+	}
+
+	v := reflect.ValueOf(cfg).Elem()
+	walkLeaves(v, "", func(path string, _ reflect.Value) {
+		sources[path] = SourceDefault
+		if _, ok := positions[path]; ok {
+			sources[path] = SourceFile
+		}
+	})
+
+	origins := make(map[string]string)
+	for _, kv := range opts.Environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		path := envPath(v, name)
+		if path == "" {
+			continue
+		}
+		if err := setPath(v, path, value); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		sources[path] = SourceEnv
+		origins[path] = name
+	}
+
+	for _, path := range sortedKeys(opts.Flags) {
+		if err := setPath(v, path, opts.Flags[path]); err != nil {
+			return nil, nil, fmt.Errorf("flag for %s: %w", path, err)
+		}
+		sources[path] = SourceFlag
+		origins[path] = "command line"
+	}
+
+	// Entries created by overrides (e.g. a new profile) default the rest
+	walkLeaves(v, "", func(path string, _ reflect.Value) {
+		if _, ok := sources[path]; !ok {
+			sources[path] = SourceDefault
+		}
+	})
+
+	if errs := cfg.validate(); len(errs) > 0 {
+		for i, fe := range errs {
+			if origin, ok := origins[fe.Path]; ok {
+				errs[i].Message += fmt.Sprintf(" (set by %s)", origin)
+			} else if node, ok := positions[fe.Path]; ok {
+				errs[i].Line, errs[i].Column = node.Line, node.Column
+			}
+		}
+		return nil, nil, &ValidationError{File: opts.Path, Errors: errs}
+	}
+	return cfg, sources, nil
+}
+
+// EnvName returns the environment variable overriding the given key path.
+func EnvName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(path))
+}
+
+// envPath finds the leaf key path an environment variable refers to.
+func envPath(v reflect.Value, name string) string {
+	var found string
+	walkLeaves(v, "", func(path string, _ reflect.Value) {
+		if EnvName(path) == name {
+			found = path
+		}
+	})
+	return found
+}
+
+// setPath parses raw according to the type of the field at the dotted key
+// path and stores it. Missing map entries (e.g. a new profile) are created.
+func setPath(v reflect.Value, path, raw string) error {
+	key, rest, _ := strings.Cut(path, ".")
+
+	switch v.Kind() {
+	case reflect.Struct:
+		field, ok := fieldByName(v.Type(), key)
+		if !ok {
+			return fmt.Errorf("unknown key %q", key)
+		}
+		fv := v.FieldByIndex(field.Index)
+		if rest == "" {
+			return setValue(fv, raw)
+		}
+		return setPath(fv, rest, raw)
+	case reflect.Map:
+		if rest == "" {
+			return fmt.Errorf("%q needs a field name", key)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		// Map elements are not addressable; update a copy and store it back
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(reflect.ValueOf(key)); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setPath(elem, rest, raw); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key), elem)
+		return nil
+	}
+	return fmt.Errorf("unknown key %q", key)
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// secrets are the keys whose values Effective masks, since the output ends
+// up in terminals, logs and bug reports. Header values often hold tokens.
+var secrets = map[string]bool{
+	"notifications.slack.webhook_url": true,
+	"notifications.webhook.headers":   true,
+	"notifications.email.password":    true,
+}
+
+// Effective renders cfg as YAML with the source of each value as a trailing
+// comment, for "devtestrider config show --effective". Secrets are masked.
+func Effective(cfg *Config, sources Sources) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, err
+	}
+	annotate(&doc, "", sources)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+func annotate(node *yaml.Node, prefix string, sources Sources) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		path := joinPath(prefix, node.Content[i].Value)
+		value := node.Content[i+1]
+		if secrets[path] {
+			mask(value)
+		}
+		if source, ok := sources[path]; ok {
+			if value.Kind == yaml.SequenceNode {
+				value.Style = yaml.FlowStyle
+			}
+			value.LineComment = source
+			continue
+		}
+		annotate(value, path, sources)
+	}
+}
+
+// mask hides a set scalar, or every value of a mapping.
+func mask(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value != "" {
+			node.Value, node.Style = "********", 0
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			mask(node.Content[i])
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveMasksSecrets(t *testing.T) {
+	cfg, sources, err := Resolve(Options{
+		Path:    filepath.Join(t.TempDir(), "testrider.yml"),
+		Environ: []string{EnvPrefix + "NOTIFICATIONS_EMAIL_PASSWORD=hunter2"},
+		Flags: map[string]string{
+			"notifications.slack.webhook_url": "https://hooks.slack.com/services/T0/B0/secret",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Notifications.Webhook.Headers = map[string]string{"Authorization": "Bearer token"}
+
+	out, err := Effective(cfg, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "secret", "Bearer token"} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("effective config shows %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(string(out), "password: '********' # env") {
+		t.Errorf("password should be masked but keep its source:\n%s", out)
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// SchemaURL is where the published schema for testrider.yml can be fetched
+// by editors, e.g. through a yaml-language-server modeline.
+const SchemaURL = "https://raw.githubusercontent.com/ismailtsdln/DevTestrider/main/internal/config/testrider.schema.json"
+
+// descriptions documents config keys in the generated JSON Schema.
+var descriptions = map[string]string{
+	"watch":                           "Which files are watched for changes.",
+	"watch.paths":                     "Directories watched recursively.",
+	"watch.ignore":                    "Paths containing any of these substrings are ignored.",
+	"watch.backend":                   "Change detection backend; auto uses polling on network and shared filesystems.",
+	"watch.poll_interval":             "How often the polling backend scans the watched trees.",
+	"report":                          "Report generation after each run.",
+	"report.formats":                  "Report formats written for every run.",
+	"report.output_dir":               "Directory reports are written to.",
+	"report.name":                     "Go text/template for report file names without extension, with .Time, .Timestamp, .Profile, .Branch and .Commit.",
+	"report.retention":                "Limits on the reports kept in output_dir; 0 disables a limit.",
+	"report.retention.keep":           "How many of the latest runs' reports are kept.",
+	"report.retention.max_age":        "Reports older than this are deleted.",
+	"report.retention.max_size_mb":    "Oldest reports are deleted while all of them together exceed this size.",
+	"report.markdown":                 "The Markdown summary for pull request comments and CI step summaries.",
+	"report.markdown.baseline":        "Run to compare coverage with: a history file or the output of --format json.",
+	"report.markdown.output_lines":    "Lines of a failing test's output shown, from the end; 0 shows all.",
+	"report.markdown.step_summary":    "Also append the summary to $GITHUB_STEP_SUMMARY when it is set.",
+	"report.trend_runs":               "How many runs the HTML report charts trends over, the current one included; 0 leaves trends and run-to-run changes out. Needs the history.",
+	"notifications":                   "Notifications sent after each run.",
+	"notifications.enable":            "Send notifications at all.",
+	"notifications.channels":          "Channels notifications are sent to.",
+	"notifications.slack":             "Slack incoming webhook channel.",
+	"notifications.slack.webhook_url": "Incoming webhook URL.",
+	"notifications.webhook":           "Generic HTTP webhook channel.",
+	"notifications.webhook.url":       "Endpoint the summary is sent to.",
+	"notifications.webhook.method":    "HTTP method.",
+	"notifications.webhook.headers":   "Extra request headers.",
+	"notifications.webhook.template":  "Go text/template for the request body; the default is a JSON summary.",
+	"notifications.email":             "SMTP email channel.",
+	"notifications.email.host":        "SMTP server host.",
+	"notifications.email.port":        "SMTP server port.",
+	"notifications.email.from":        "Sender address.",
+	"notifications.email.to":          "Recipient addresses.",
+	"server":                          "Dashboard server.",
+	"server.port":                     "Port the dashboard and API listen on.",
+	"git":                             "Git integration.",
+	"git.enable":                      "Test the packages changed by commits, checkouts, pulls and rebases.",
+	"git.interval":                    "How often HEAD is checked for moves.",
+	"history":                         "Past runs kept on disk for analytics.",
+	"history.enable":                  "Save every finished run.",
+	"history.dir":                     "Directory holding one JSON file per run.",
+	"history.max_runs":                "How many runs are kept; 0 keeps all of them.",
+	"analytics":                       "Slow-test and duration regression analytics.",
+	"analytics.baseline_runs":         "How many past runs the median and p95 duration baselines are computed from.",
+	"analytics.regression_factor":     "Flag tests running this many times slower than their median.",
+	"analytics.min_duration":          "Tests faster than this are never flagged as regressions.",
+	"analytics.slowest":               "How many tests the slowest tests leaderboard lists.",
+	"profile":                         "Name of the profile used for test runs.",
+	"profiles":                        "Named sets of go test options.",
+	"profiles.*.tags":                 "Build tags passed with -tags.",
+	"profiles.*.run":                  "Only run tests matching this regular expression (-run).",
+	"profiles.*.race":                 "Enable the race detector (-race).",
+	"profiles.*.short":                "Pass -short.",
+	"profiles.*.args":                 "Extra arguments passed to go test.",
+	"profiles.*.timeout":              "How long a package's tests may run before they are stopped and reported as hung; 10m by default.",
+	"profiles.*.package_timeouts":     "Timeouts for packages matching an import path pattern (\"/...\" and path.Match wildcards); the longest matching pattern wins.",
+}
+
+// Schema returns a JSON Schema (draft 2020-12) describing testrider.yml,
+// generated from the Config type so that it never drifts from the loader.
+func Schema() ([]byte, error) {
+	schema := schemaFor(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaURL
+	schema["title"] = "DevTestrider configuration (testrider.yml)"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func schemaFor(t reflect.Type, path string) map[string]any {
+	s := map[string]any{}
+	if desc, ok := descriptions[path]; ok {
+		s["description"] = desc
+	}
+
+	switch {
+	case t == durationType:
+		s["type"] = "string"
+		s["pattern"] = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+	case t.Kind() == reflect.Pointer:
+		return schemaFor(t.Elem(), path)
+	case t.Kind() == reflect.Struct:
+		props := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			if name := yamlName(t.Field(i)); name != "" {
+				props[name] = schemaFor(t.Field(i).Type, joinPath(path, name))
+			}
+		}
+		s["type"] = "object"
+		s["properties"] = props
+		s["additionalProperties"] = false
+	case t.Kind() == reflect.Map:
+		s["type"] = "object"
+		s["additionalProperties"] = schemaFor(t.Elem(), joinPath(path, "*"))
+	case t.Kind() == reflect.Slice:
+		items := schemaFor(t.Elem(), "")
+		if allowed, ok := enums[path]; ok {
+			items["enum"] = sorted(allowed)
+		}
+		s["type"] = "array"
+		s["items"] = items
+	case t.Kind() == reflect.String:
+		s["type"] = "string"
+		if allowed, ok := enums[path]; ok {
+			s["enum"] = sorted(allowed)
+		}
+	case t.Kind() == reflect.Bool:
+		s["type"] = "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s["type"] = "integer"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s["type"] = "number"
+	}
+	return s
+}
+
+// sortedKeys is used to keep generated output and messages stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sorted(values []string) []string {
+	out := append([]string(nil), values...)
+	sort.Strings(out)
+	return out
+}
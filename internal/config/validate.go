@@ -0,0 +1,330 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// enums lists the accepted values of string and string-list fields. The same
+// table drives validation and the published JSON Schema.
+var enums = map[string][]string{
+	"watch.backend":            {"auto", "fsnotify", "polling"},
+	"report.formats":           {"html", "pdf", "json", "markdown"},
+	"notifications.channels":   {"desktop", "browser", "slack", "webhook", "email"},
+	"notifications.rules.when": {"always", "failure", "transition", "new_failures", "coverage_drop"},
+}
+
+// FieldError is a single problem found in a config file.
+type FieldError struct {
+	Path    string // Dotted key path, e.g. "watch.backend"
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e FieldError) Error() string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d:%d: ", e.Line, e.Column)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&b, "%s: ", e.Path)
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ValidationError collects every FieldError of a config file.
+type ValidationError struct {
+	File   string
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		lines[i] = fmt.Sprintf("%s: %s", e.File, fe.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the values of an already assembled config.
+func (c *Config) Validate() error {
+	errs := c.validate()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{File: "config", Errors: errs}
+}
+
+func (c *Config) validate() []FieldError {
+	var errs []FieldError
+	add := func(path, format string, args ...any) {
+		errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	walkLeaves(reflect.ValueOf(c).Elem(), "", func(path string, v reflect.Value) {
+		if v.Type() == durationType && v.Int() < 0 {
+			add(path, "must not be negative")
+		}
+		if strings.HasSuffix(path, ".retries") && v.Int() < 0 {
+			add(path, "must not be negative")
+		}
+
+		allowed, ok := enums[path]
+		if !ok {
+			return
+		}
+		var values []string
+		switch v.Kind() {
+		case reflect.String:
+			values = []string{v.String()}
+		case reflect.Slice:
+			values = v.Interface().([]string)
+		}
+		for _, value := range values {
+			if !containsString(allowed, value) {
+				add(path, "invalid value %q (want one of: %s)", value, strings.Join(allowed, ", "))
+			}
+		}
+	})
+
+	if len(c.Watch.Paths) == 0 {
+		add("watch.paths", "at least one path is required")
+	}
+	if c.Profile != "" {
+		if _, ok := c.Profiles[c.Profile]; !ok {
+			add("profile", "unknown profile %q (defined: %s)", c.Profile, strings.Join(sortedKeys(c.Profiles), ", "))
+		}
+	}
+	for _, name := range sortedKeys(c.Profiles) {
+		timeouts := c.Profiles[name].PackageTimeouts
+		for _, pattern := range sortedKeys(timeouts) {
+			path := "profiles." + name + ".package_timeouts"
+			if pattern == "" {
+				add(path, "package pattern must not be empty")
+			}
+			if timeouts[pattern] < 0 {
+				add(path, "%s: must not be negative", pattern)
+			}
+		}
+	}
+	if n := c.Notifications; n.Enable {
+		if containsString(n.Channels, "slack") && n.Slack.WebhookURL == "" {
+			add("notifications.slack.webhook_url", "required by the slack channel")
+		}
+		if containsString(n.Channels, "webhook") && n.Webhook.URL == "" {
+			add("notifications.webhook.url", "required by the webhook channel")
+		}
+		if containsString(n.Channels, "email") {
+			if n.Email.Host == "" {
+				add("notifications.email.host", "required by the email channel")
+			}
+			if n.Email.From == "" {
+				add("notifications.email.from", "required by the email channel")
+			}
+			if len(n.Email.To) == 0 {
+				add("notifications.email.to", "required by the email channel")
+			}
+		}
+	}
+	quiet := c.Notifications.Rules.QuietHours
+	if (quiet.Start == "") != (quiet.End == "") {
+		add("notifications.rules.quiet_hours", "start and end must be set together")
+	}
+	for _, field := range []struct{ path, value string }{
+		{"notifications.rules.quiet_hours.start", quiet.Start},
+		{"notifications.rules.quiet_hours.end", quiet.End},
+	} {
+		if _, err := ParseClock(field.value); field.value != "" && err != nil {
+			add(field.path, "%v", err)
+		}
+	}
+	for _, channel := range sortedKeys(c.Notifications.Rules.ChannelRateLimits) {
+		limit := c.Notifications.Rules.ChannelRateLimits[channel]
+		if !containsString(enums["notifications.channels"], channel) {
+			add("notifications.rules.channel_rate_limits", "unknown channel %q", channel)
+		}
+		if limit < 0 {
+			add("notifications.rules.channel_rate_limits", "%s: must not be negative", channel)
+		}
+	}
+	if c.Report.Name == "" {
+		add("report.name", "must not be empty")
+	} else if _, err := template.New("name").Parse(c.Report.Name); err != nil {
+		add("report.name", "invalid template: %v", err)
+	}
+	if c.Report.Retention.Keep < 0 {
+		add("report.retention.keep", "must not be negative")
+	}
+	if c.Report.Retention.MaxAge < 0 {
+		add("report.retention.max_age", "must not be negative")
+	}
+	if c.Report.Retention.MaxSizeMB < 0 {
+		add("report.retention.max_size_mb", "must not be negative")
+	}
+	if c.Report.Markdown.OutputLines < 0 {
+		add("report.markdown.output_lines", "must not be negative")
+	}
+	if c.Report.TrendRuns < 0 {
+		add("report.trend_runs", "must not be negative")
+	}
+	if c.History.Enable && c.History.Dir == "" {
+		add("history.dir", "required when history is enabled")
+	}
+	if c.History.MaxRuns < 0 {
+		add("history.max_runs", "must not be negative")
+	}
+	if c.Analytics.BaselineRuns < 1 {
+		add("analytics.baseline_runs", "must be at least 1, got %d", c.Analytics.BaselineRuns)
+	}
+	if c.Analytics.RegressionFactor <= 1 {
+		add("analytics.regression_factor", "must be greater than 1, got %g", c.Analytics.RegressionFactor)
+	}
+	if c.Analytics.Slowest < 0 {
+		add("analytics.slowest", "must not be negative")
+	}
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		add("server.port", "must be between 0 and 65535, got %d", c.Server.Port)
+	}
+	return errs
+}
+
+// ParseClock parses a local "HH:MM" time into minutes since midnight.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// unknownKeys reports mapping keys in node that have no matching field in t.
+func unknownKeys(node *yaml.Node, t reflect.Type, prefix string) []FieldError {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var errs []FieldError
+	switch {
+	case t.Kind() == reflect.Struct && t != durationType && node.Kind == yaml.MappingNode:
+		var known []string
+		for i := 0; i < t.NumField(); i++ {
+			if name := yamlName(t.Field(i)); name != "" {
+				known = append(known, name)
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			path := joinPath(prefix, key.Value)
+			field, ok := fieldByName(t, key.Value)
+			if !ok {
+				msg := fmt.Sprintf("unknown key %q", key.Value)
+				if s := suggest(key.Value, known); s != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+				errs = append(errs, FieldError{Path: path, Line: key.Line, Column: key.Column, Message: msg})
+				continue
+			}
+			errs = append(errs, unknownKeys(value, field.Type, path)...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, unknownKeys(node.Content[i+1], t.Elem(), joinPath(prefix, node.Content[i].Value))...)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			errs = append(errs, unknownKeys(item, t.Elem(), prefix)...)
+		}
+	}
+	return errs
+}
+
+// collectPositions records the value node of every key path in the document.
+func collectPositions(node *yaml.Node, prefix string, out map[string]*yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		path := joinPath(prefix, node.Content[i].Value)
+		out[path] = node.Content[i+1]
+		collectPositions(node.Content[i+1], path, out)
+	}
+}
+
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+func fromTypeError(err *yaml.TypeError, positions map[string]*yaml.Node) []FieldError {
+	var errs []FieldError
+	for _, msg := range err.Errors {
+		fe := FieldError{Message: msg}
+		if m := typeErrorLine.FindStringSubmatch(msg); m != nil {
+			fe.Line, _ = strconv.Atoi(m[1])
+			fe.Column = 1
+			fe.Message = m[2]
+			// Attribute the error to the innermost key on that line
+			for path, node := range positions {
+				if node.Line == fe.Line && len(path) > len(fe.Path) {
+					fe.Path, fe.Column = path, node.Column
+				}
+			}
+		}
+		errs = append(errs, fe)
+	}
+	return errs
+}
+
+// suggest returns the known key closest to key, if any is close enough to be
+// a likely typo (including camelCase spellings of snake_case keys).
+func suggest(key string, known []string) string {
+	normalize := func(s string) string {
+		return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(s))
+	}
+
+	best, bestDist := "", 3
+	for _, k := range known {
+		if normalize(k) == normalize(key) {
+			return k
+		}
+		if d := levenshtein(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func containsString(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,386 @@
+// Package console prints runs to the terminal as a stable, sorted summary.
+package console
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+)
+
+// Output formats.
+const (
+	// FormatPlain names each failing test with an output excerpt and
+	// collapses passing packages into one line.
+	FormatPlain = "plain"
+	// FormatCompact prints one line per run and one per failing test.
+	FormatCompact = "compact"
+	// FormatVerbose lists every package and test, with full failure output.
+	FormatVerbose = "verbose"
+)
+
+// Formats lists the accepted values of --format.
+var Formats = []string{FormatPlain, FormatCompact, FormatVerbose, FormatJSON, FormatEvents}
+
+// Lines of a failing test's output shown by FormatPlain.
+const excerptLines = 10
+
+// Printer writes run summaries and status lines. Colour is disabled when the
+// writer is not a terminal or NO_COLOR is set.
+type Printer struct {
+	w      io.Writer
+	format string
+
+	info, pass, fail, skip, dim lipgloss.Style
+}
+
+// New creates a Printer for one of Formats.
+func New(w io.Writer, format string) (*Printer, error) {
+	switch format {
+	case FormatPlain, FormatCompact, FormatVerbose:
+	case "":
+		format = FormatPlain
+	default:
+		return nil, fmt.Errorf("unknown text format %q (want one of: %s, %s, %s)", format, FormatPlain, FormatCompact, FormatVerbose)
+	}
+
+	r := lipgloss.NewRenderer(w)
+	if !colorEnabled(w) {
+		r.SetColorProfile(termenv.Ascii)
+	}
+	return &Printer{
+		w:      w,
+		format: format,
+		info:   r.NewStyle().Foreground(lipgloss.Color("86")),
+		pass:   r.NewStyle().Foreground(lipgloss.Color("42")),
+		fail:   r.NewStyle().Foreground(lipgloss.Color("160")),
+		skip:   r.NewStyle().Foreground(lipgloss.Color("220")),
+		dim:    r.NewStyle().Foreground(lipgloss.Color("241")),
+	}, nil
+}
+
+func colorEnabled(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// RunStarted, Info, Warn and Result make a Printer an orchestrator.Output.
+
+func (p *Printer) RunStarted(label, detail string) {
+	fmt.Fprintf(p.w, "\n%s %s\n", p.info.Render(label), detail)
+}
+
+func (p *Printer) Info(label, detail string) {
+	fmt.Fprintf(p.w, "%s %s\n", p.info.Render(label), detail)
+}
+
+func (p *Printer) Warn(label, detail string) {
+	fmt.Fprintf(p.w, "%s %s\n", p.fail.Render(label), detail)
+}
+
+// Result prints the summary of a run. Packages and tests are sorted so the
+// same run always prints the same way.
+func (p *Printer) Result(result *engine.TestResult) {
+	switch p.format {
+	case FormatCompact:
+		p.compact(result)
+	case FormatVerbose:
+		p.verbose(result)
+	default:
+		p.plain(result)
+	}
+}
+
+func (p *Printer) status(result *engine.TestResult) string {
+	if result.Success {
+		return p.pass.Render("PASSED")
+	}
+	return p.fail.Render("FAILED")
+}
+
+func (p *Printer) counts(result *engine.TestResult) string {
+	s := fmt.Sprintf("%d passed, %d failed", result.PassedTests, result.FailedTests)
+	if result.SkippedTests > 0 {
+		s += fmt.Sprintf(", %d skipped", result.SkippedTests)
+	}
+	return s + fmt.Sprintf(" in %s (%.2fs)", plural(len(result.Packages), "package"), result.Duration)
+}
+
+func (p *Printer) compact(result *engine.TestResult) {
+	fmt.Fprintf(p.w, "%s %s\n", p.status(result), p.counts(result))
+	for _, name := range packageNames(result) {
+		pkg := result.Packages[name]
+		if note := failureNote(pkg); note != "" {
+			fmt.Fprintf(p.w, "  %s %s %s\n", p.fail.Render("✗"), shortName(name), note)
+		}
+		for _, err := range pkg.BuildErrors {
+			fmt.Fprintf(p.w, "  %s %s %s\n", p.fail.Render("✗"), buildErrorPath(result, name, err), err.Message)
+		}
+		for _, test := range failingTests(pkg) {
+			fmt.Fprintf(p.w, "  %s %s.%s", p.fail.Render("✗"), shortName(name), test.Name)
+			if loc := location(result, pkg, test); loc != "" {
+				fmt.Fprintf(p.w, " %s", p.dim.Render(loc))
+			}
+			fmt.Fprintln(p.w)
+		}
+	}
+	if len(result.Issues) > 0 {
+		fmt.Fprintf(p.w, "  %s %s\n", p.skip.Render("!"), plural(len(result.Issues), "vet issue"))
+	}
+}
+
+func (p *Printer) plain(result *engine.TestResult) {
+	fmt.Fprintf(p.w, "Status: %s %s\n", p.status(result), p.counts(result))
+
+	passed := 0
+	for _, name := range packageNames(result) {
+		pkg := result.Packages[name]
+		if !pkg.Failed() {
+			passed++
+			continue
+		}
+		p.failedPackage(result, pkg, excerptLines)
+	}
+	if passed > 0 {
+		fmt.Fprintf(p.w, "  %s %s passed\n", p.pass.Render("✓"), plural(passed, "package"))
+	}
+	p.issues(result)
+}
+
+func (p *Printer) verbose(result *engine.TestResult) {
+	fmt.Fprintf(p.w, "Status: %s %s\n", p.status(result), p.counts(result))
+
+	for _, name := range packageNames(result) {
+		pkg := result.Packages[name]
+		if pkg.Failed() {
+			p.failedPackage(result, pkg, 0)
+		} else {
+			fmt.Fprintf(p.w, "  %s %s %s %s\n", p.icon(pkg.Status), name, p.coverage(pkg), p.dim.Render(fmt.Sprintf("(%.2fs)", pkg.Duration)))
+		}
+		// Failures were printed with their output above
+		printed := make(map[*engine.TestCase]bool)
+		for _, test := range failingTests(pkg) {
+			printed[test] = true
+		}
+		for _, test := range pkg.AllTests() {
+			if printed[test] {
+				continue
+			}
+			indent := strings.Repeat("  ", test.Depth())
+			fmt.Fprintf(p.w, "      %s%s %s %s\n", indent, p.icon(test.Status), test.ShortName(), p.dim.Render(fmt.Sprintf("(%.2fs)", test.Duration)))
+		}
+	}
+	p.issues(result)
+}
+
+// failedPackage prints a failing package and its failing tests with at most
+// excerpt lines of output each; 0 prints all of it.
+func (p *Printer) failedPackage(result *engine.TestResult, pkg *engine.PackageResult, excerpt int) {
+	fmt.Fprintf(p.w, "  %s %s %s %s", p.icon("FAIL"), pkg.Name, p.coverage(pkg), p.dim.Render(fmt.Sprintf("(%.2fs)", pkg.Duration)))
+	if note := failureNote(pkg); note != "" {
+		fmt.Fprintf(p.w, " %s", p.fail.Render(note))
+	}
+	fmt.Fprintln(p.w)
+
+	for _, err := range pkg.BuildErrors {
+		fmt.Fprintf(p.w, "    %s %s %s\n", p.fail.Render("✗"), p.dim.Render(buildErrorPath(result, pkg.Name, err)), err.Message)
+	}
+	// Newer go versions put the stack in the failing test's output; older
+	// ones only in the package's
+	if len(pkg.Stack) > 0 && len(failingTests(pkg)) == 0 {
+		if file, line, ok := engine.PanicLocation(pkg.Stack); ok {
+			fmt.Fprintf(p.w, "    %s\n", p.dim.Render(fmt.Sprintf("at %s:%d", file, line)))
+		}
+		p.excerpt(pkg.Stack, excerpt)
+	}
+
+	if pkg.Hung != nil {
+		p.hung(result, pkg)
+	}
+
+	for _, test := range failingTests(pkg) {
+		fmt.Fprintf(p.w, "    %s %s", p.fail.Render("✗"), test.Name)
+		if loc := location(result, pkg, test); loc != "" {
+			fmt.Fprintf(p.w, " %s", p.dim.Render(loc))
+		}
+		fmt.Fprintln(p.w)
+		// The output of a hung test ends in the goroutine dump summarized
+		// above
+		if pkg.Hung != nil && excerpt > 0 && slices.Contains(pkg.Hung.Running, test.Name) {
+			continue
+		}
+		p.excerpt(test.Output, excerpt)
+	}
+}
+
+// hung prints where a timed out package's test and the goroutines in the
+// package were blocked.
+func (p *Printer) hung(result *engine.TestResult, pkg *engine.PackageResult) {
+	h := pkg.Hung
+	what := "no test"
+	if h.Test != "" {
+		what = h.Test
+	}
+	fmt.Fprintf(p.w, "    %s %s after %.1fs %s\n", p.fail.Render("hung:"), what, h.Elapsed, p.dim.Render(fmt.Sprintf("(timeout %s)", time.Duration(h.Timeout*float64(time.Second)))))
+	for i, g := range h.Goroutines {
+		frame, ok := topFrame(g, pkg.Name)
+		if !ok {
+			continue
+		}
+		label := fmt.Sprintf("goroutine %d", g.ID)
+		if i == 0 && h.Test != "" && h.File != "" {
+			label = "test goroutine"
+		}
+		fmt.Fprintf(p.w, "      %s [%s] %s %s\n", label, g.State, p.dim.Render(fmt.Sprintf("%s:%d", relativePath(result, pkg.Name, frame.File), frame.Line)), frame.Function)
+	}
+}
+
+// topFrame is the innermost frame of a goroutine inside pkg.
+func topFrame(g engine.Goroutine, pkg string) (engine.Frame, bool) {
+	for _, frame := range g.Frames {
+		if strings.HasPrefix(frame.Function, pkg+".") || strings.HasPrefix(frame.Function, pkg+"_test.") {
+			return frame, true
+		}
+	}
+	return engine.Frame{}, false
+}
+
+// excerpt prints the last excerpt lines of output, or for a panic the
+// first ones from the panic message on; 0 prints all of it.
+func (p *Printer) excerpt(lines []string, excerpt int) {
+	if crash := engine.PanicExcerpt(lines); crash != nil && excerpt > 0 {
+		if len(crash) > excerpt {
+			crash = append(crash[:excerpt:excerpt], p.dim.Render(fmt.Sprintf("… %d more lines", len(crash)-excerpt)))
+		}
+		lines, excerpt = crash, 0
+	}
+	if excerpt > 0 && len(lines) > excerpt {
+		fmt.Fprintf(p.w, "        %s\n", p.dim.Render(fmt.Sprintf("… %d earlier lines", len(lines)-excerpt)))
+		lines = lines[len(lines)-excerpt:]
+	}
+	for _, line := range lines {
+		fmt.Fprintf(p.w, "        %s\n", strings.TrimSpace(line))
+	}
+}
+
+// failureNote explains package failures that are not plain test failures.
+func failureNote(pkg *engine.PackageResult) string {
+	switch pkg.Status {
+	case engine.StatusBuildFailed:
+		return "[build failed]"
+	case engine.StatusPanic:
+		return "[panic]"
+	case engine.StatusTimeout:
+		return "[timed out]"
+	}
+	return ""
+}
+
+// buildErrorPath is the file:line of a compiler error relative to the
+// project root.
+func buildErrorPath(result *engine.TestResult, pkg string, err engine.ValidationIssue) string {
+	return fmt.Sprintf("%s:%d", filepath.Join(result.ModuleDir(pkg), err.File), err.Line)
+}
+
+func (p *Printer) issues(result *engine.TestResult) {
+	if len(result.Issues) == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "  %s\n", p.skip.Render(plural(len(result.Issues), "vet issue")))
+	for _, issue := range result.Issues {
+		fmt.Fprintf(p.w, "    %s\n", issue)
+	}
+}
+
+func (p *Printer) icon(status string) string {
+	switch status {
+	case "PASS":
+		return p.pass.Render("✓")
+	case "FAIL", engine.StatusBuildFailed, engine.StatusPanic, engine.StatusTimeout:
+		return p.fail.Render("✗")
+	case "SKIP":
+		return p.skip.Render("↷")
+	}
+	return p.dim.Render("·")
+}
+
+func (p *Printer) coverage(pkg *engine.PackageResult) string {
+	if pkg.Coverage <= 0 {
+		return p.dim.Render("N/A")
+	}
+	style := p.fail
+	if pkg.Coverage > 80 {
+		style = p.pass
+	} else if pkg.Coverage > 50 {
+		style = p.skip
+	}
+	return style.Render(fmt.Sprintf("%.1f%%", pkg.Coverage))
+}
+
+// location is the failure's file:line relative to the project root when the
+// package directory is known, or to the package directory otherwise. A hung
+// test is located where it was blocked.
+func location(result *engine.TestResult, pkg *engine.PackageResult, test *engine.TestCase) string {
+	file, line, ok := test.Location()
+	if !ok && pkg.Hung != nil && pkg.Hung.Test == test.Name && pkg.Hung.File != "" {
+		file, line, ok = pkg.Hung.File, pkg.Hung.Line, true
+	}
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", relativePath(result, pkg.Name, file), line)
+}
+
+// relativePath makes a file named in test output relative to the project
+// root when possible.
+func relativePath(result *engine.TestResult, pkg, file string) string {
+	if filepath.IsAbs(file) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+				file = rel
+			}
+		}
+	} else if dir := result.PackageDir(pkg); dir != "" {
+		file = filepath.Join(dir, file)
+	}
+	return file
+}
+
+func packageNames(result *engine.TestResult) []string {
+	names := make([]string, 0, len(result.Packages))
+	for name := range result.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// failingTests are the tests that failed themselves; parents failing only
+// through their subtests are left out.
+func failingTests(pkg *engine.PackageResult) []*engine.TestCase {
+	return pkg.Failures()
+}
+
+// shortName is the last element of an import path.
+func shortName(pkg string) string {
+	return pkg[strings.LastIndex(pkg, "/")+1:]
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
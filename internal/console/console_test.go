@@ -0,0 +1,88 @@
+package console
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// run is testdata/run.json: example.com/shop/pay fails, cart passes with a
+// skipped test and util passes. The log lists the packages out of order.
+func run(t *testing.T) *engine.TestResult {
+	t.Helper()
+	f, err := os.Open("testdata/run.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := engine.NewRunner().Ingest(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Issues = []string{"pay/pay.go:3:6: Charge is unused"}
+	return result
+}
+
+func TestPrinterGolden(t *testing.T) {
+	for _, format := range []string{FormatPlain, FormatCompact, FormatVerbose} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			p, err := New(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Result(run(t))
+
+			// Printed twice, the same run must come out the same
+			var again bytes.Buffer
+			p.w = &again
+			p.Result(run(t))
+			if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+				t.Errorf("output differs between two prints of the same run:\n%s\n---\n%s", buf.Bytes(), again.Bytes())
+			}
+
+			golden := filepath.Join("testdata", format+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output does not match %s (rerun with -update to accept):\n%s", golden, buf.Bytes())
+			}
+		})
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	// The master side of a pseudo-terminal is a terminal
+	tty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("no pseudo-terminal:", err)
+	}
+	defer tty.Close()
+
+	// Setenv restores NO_COLOR when the test ends
+	t.Setenv("NO_COLOR", "")
+	os.Unsetenv("NO_COLOR")
+	if !colorEnabled(tty) {
+		t.Error("colour disabled on a terminal")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(tty) {
+		t.Error("colour enabled on a terminal with NO_COLOR set")
+	}
+	if colorEnabled(&bytes.Buffer{}) {
+		t.Error("colour enabled on a writer that is not a terminal")
+	}
+}
@@ -0,0 +1,101 @@
+package console
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ismailtsdln/DevTestrider/internal/analytics"
+)
+
+// Entries of each list a comparison prints outside of FormatVerbose.
+const diffEntries = 10
+
+// Comparison prints what changed from the run named base to the one named
+// head.
+func (p *Printer) Comparison(base, head string, c *analytics.Comparison) {
+	fmt.Fprintf(p.w, "%s %s → %s\n", p.info.Render("Comparing"), base, head)
+
+	p.refs(p.fail.Render("✗"), "new failure", c.NewlyFailing)
+	p.refs(p.pass.Render("✓"), "fixed test", c.NewlyPassing)
+	p.refs(p.info.Render("+"), "added test", c.Added)
+	p.refs(p.dim.Render("-"), "removed test", c.Removed)
+	p.names(p.info.Render("+"), "added package", c.AddedPackages)
+	p.names(p.dim.Render("-"), "removed package", c.RemovedPackages)
+
+	if len(c.Coverage) > 0 {
+		fmt.Fprintf(p.w, "  Coverage\n")
+		for i, d := range c.Coverage {
+			if p.truncated(i, len(c.Coverage)) {
+				break
+			}
+			fmt.Fprintf(p.w, "    %s %5.1f%% → %5.1f%% %s\n", d.Package, d.Previous, d.Current, p.change(d.Delta, "%+.1f", 0.05, true))
+		}
+	}
+
+	fmt.Fprintf(p.w, "  Duration %.2fs → %.2fs %s\n", c.Duration.Previous, c.Duration.Current, p.change(c.Duration.Delta, "%+.2fs", 0.005, false))
+	for i, d := range c.Durations {
+		if p.truncated(i, len(c.Durations)) {
+			break
+		}
+		name := d.Package
+		if d.Test != "" {
+			name = fmt.Sprintf("%s %s", d.Test, p.dim.Render(d.Package))
+		}
+		fmt.Fprintf(p.w, "    %s %.3fs → %.3fs %s\n", name, d.Previous, d.Current, p.change(d.Delta, "%+.3fs", 0, false))
+	}
+}
+
+func (p *Printer) refs(icon, noun string, refs []analytics.TestRef) {
+	if len(refs) == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "  %s %s\n", icon, plural(len(refs), noun))
+	for i, ref := range refs {
+		if p.truncated(i, len(refs)) {
+			break
+		}
+		if ref.Test == "" {
+			fmt.Fprintf(p.w, "    %s\n", ref.Package)
+		} else {
+			fmt.Fprintf(p.w, "    %s %s\n", ref.Test, p.dim.Render(ref.Package))
+		}
+	}
+}
+
+func (p *Printer) names(icon, noun string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "  %s %s\n", icon, plural(len(names), noun))
+	for i, name := range names {
+		if p.truncated(i, len(names)) {
+			break
+		}
+		fmt.Fprintf(p.w, "    %s\n", name)
+	}
+}
+
+// truncated reports whether entry i of n is past the ones shown, printing
+// how many are left out when it is the first such entry.
+func (p *Printer) truncated(i, n int) bool {
+	if p.format == FormatVerbose || i < diffEntries {
+		return false
+	}
+	if i == diffEntries {
+		fmt.Fprintf(p.w, "    %s\n", p.dim.Render(fmt.Sprintf("… %d more", n-i)))
+	}
+	return true
+}
+
+// change colours a delta by whether it is an improvement: growth is good
+// when up is true. Changes within noise are dimmed.
+func (p *Printer) change(delta float64, format string, noise float64, up bool) string {
+	text := fmt.Sprintf(format, delta)
+	switch {
+	case math.Abs(delta) <= noise:
+		return p.dim.Render(text)
+	case (delta > 0) == up:
+		return p.pass.Render(text)
+	}
+	return p.fail.Render(text)
+}
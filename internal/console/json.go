@@ -0,0 +1,101 @@
+package console
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Machine-readable formats, written as newline-delimited JSON Records.
+const (
+	// FormatJSON writes one "result" record per finished run, plus
+	// "run_start", "info" and "warn" records.
+	FormatJSON = "json"
+	// FormatEvents additionally writes a "test_event" record for every
+	// "go test -json" event as it arrives.
+	FormatEvents = "events"
+)
+
+// SchemaVersion is the version of Record. It changes whenever a field is
+// removed or changes meaning; new fields may be added without a bump.
+//
+// Version 2 nests subtests under their parent in packages.*.tests, which
+// lists top-level tests only, and counts only leaf tests in total_tests.
+// Version 3 reports packages that failed to build, panicked or timed out
+// with the status BUILD_FAIL, PANIC or TIMEOUT instead of FAIL.
+const SchemaVersion = 3
+
+// Record types.
+const (
+	RecordRunStart  = "run_start"
+	RecordTestEvent = "test_event"
+	RecordResult    = "result"
+	RecordInfo      = "info"
+	RecordWarn      = "warn"
+)
+
+// Record is one line of JSON output.
+type Record struct {
+	// SchemaVersion is always SchemaVersion.
+	SchemaVersion int `json:"schema_version"`
+	// Type is one of the Record* constants and says which fields are set.
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Label and Detail describe run_start, info and warn records, e.g.
+	// "File changed:" and the path.
+	Label  string `json:"label,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	// Event is the raw "go test -json" event of a test_event record.
+	Event *engine.GoTestEvent `json:"event,omitempty"`
+	// Result is the finished run of a result record.
+	Result *engine.TestResult `json:"result,omitempty"`
+}
+
+// JSONWriter writes Records as NDJSON. It is safe for concurrent use.
+type JSONWriter struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	events bool
+}
+
+// NewJSON creates a JSONWriter; events enables test_event records.
+func NewJSON(w io.Writer, events bool) *JSONWriter {
+	return &JSONWriter{enc: json.NewEncoder(w), events: events}
+}
+
+func (j *JSONWriter) write(r Record) {
+	r.SchemaVersion = SchemaVersion
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.enc.Encode(r)
+}
+
+func (j *JSONWriter) RunStarted(label, detail string) {
+	j.write(Record{Type: RecordRunStart, Label: label, Detail: detail})
+}
+
+func (j *JSONWriter) Info(label, detail string) {
+	j.write(Record{Type: RecordInfo, Label: label, Detail: detail})
+}
+
+func (j *JSONWriter) Warn(label, detail string) {
+	j.write(Record{Type: RecordWarn, Label: label, Detail: detail})
+}
+
+func (j *JSONWriter) Result(result *engine.TestResult) {
+	j.write(Record{Type: RecordResult, Time: result.Timestamp, Result: result})
+}
+
+// Progress is meant for engine.Runner.Progress; it writes test_event records
+// when they are enabled.
+func (j *JSONWriter) Progress(event engine.GoTestEvent) {
+	if j.events {
+		j.write(Record{Type: RecordTestEvent, Time: event.Time, Event: &event})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"bufio"
 	"bytes"
 	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 type ValidationIssue struct {
@@ -12,8 +15,31 @@ type ValidationIssue struct {
 	Message string `json:"message"`
 }
 
-func RunVet(path string) ([]string, error) {
-	cmd := exec.Command("go", "vet", path)
+func RunVet(paths ...string) ([]string, error) {
+	return runVet("", paths)
+}
+
+// Vet runs "go vet" on the given package patterns from each owning module's
+// directory, like RunPackages does for tests.
+func (r *Runner) Vet(pkgs ...string) ([]string, error) {
+	if len(r.Modules) == 0 {
+		return RunVet(pkgs...)
+	}
+
+	issues := []string{}
+	for _, group := range planModules(r.Modules, pkgs) {
+		found, err := runVet(group.module.Dir, group.patterns)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	return issues, nil
+}
+
+func runVet(dir string, paths []string) ([]string, error) {
+	cmd := exec.Command("go", append([]string{"vet"}, paths...)...)
+	cmd.Dir = dir
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -32,3 +58,17 @@ func RunVet(path string) ([]string, error) {
 
 	return []string{}, nil
 }
+
+// vetPattern matches "file.go:line:col: message" and "file.go:line: message",
+// optionally prefixed with "vet: " as vet does for type errors.
+var vetPattern = regexp.MustCompile(`^(?:vet: )?(.+?\.go):(\d+)(?::\d+)?: (.*)$`)
+
+// ParseIssue splits a "go vet" line into its location and message.
+func ParseIssue(issue string) (ValidationIssue, bool) {
+	m := vetPattern.FindStringSubmatch(strings.TrimSpace(issue))
+	if m == nil {
+		return ValidationIssue{}, false
+	}
+	line, _ := strconv.Atoi(m[2])
+	return ValidationIssue{File: m[1], Line: line, Message: m[3]}, true
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+)
+
+// Op describes the kind of change a Backend observed.
+type Op uint8
+
+const (
+	OpCreate Op = 1 << iota
+	OpWrite
+	OpRemove
+)
+
+// FileEvent is a single filesystem change reported by a Backend.
+type FileEvent struct {
+	Path string
+	Op   Op
+}
+
+// Backend is the source of raw filesystem changes for the Watcher.
+// Add watches a directory tree recursively; directories for which the
+// skip function passed to the constructor returns true are not descended into.
+// Remove stops watching a tree previously passed to Add.
+type Backend interface {
+	Add(root string) error
+	Remove(root string) error
+	Events() <-chan FileEvent
+	Errors() <-chan error
+	Close() error
+}
+
+const (
+	BackendAuto     = "auto"
+	BackendFsnotify = "fsnotify"
+	BackendPolling  = "polling"
+)
+
+const defaultPollInterval = time.Second
+
+func newBackend(cfg config.WatchConfig, skip func(string) bool) (Backend, error) {
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	switch cfg.Backend {
+	case BackendFsnotify:
+		return newFsnotifyBackend(skip)
+	case BackendPolling:
+		return newPollingBackend(interval, skip), nil
+	case "", BackendAuto:
+		for _, path := range cfg.Paths {
+			if fs, ok := remoteFilesystem(path); ok {
+				log.Printf("%s is on %s, using polling watcher", path, fs)
+				return newPollingBackend(interval, skip), nil
+			}
+		}
+		b, err := newFsnotifyBackend(skip)
+		if err != nil {
+			log.Printf("fsnotify unavailable (%v), using polling watcher", err)
+			return newPollingBackend(interval, skip), nil
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unknown watch backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,71 @@
+package engine
+
+import "sync"
+
+// FakeBackend is an in-memory Backend for the watcher tests. Changes are injected with
+// Emit instead of being read from the filesystem.
+type FakeBackend struct {
+	mu     sync.Mutex
+	roots  []string
+	events chan FileEvent
+	errors chan error
+	closed bool
+}
+
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{
+		events: make(chan FileEvent),
+		errors: make(chan error),
+	}
+}
+
+func (b *FakeBackend) Add(root string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roots = append(b.roots, root)
+	return nil
+}
+
+func (b *FakeBackend) Remove(root string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	roots := b.roots[:0]
+	for _, r := range b.roots {
+		if r != root {
+			roots = append(roots, r)
+		}
+	}
+	b.roots = roots
+	return nil
+}
+
+// Roots returns the paths passed to Add so far.
+func (b *FakeBackend) Roots() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.roots...)
+}
+
+// Emit delivers an event to the Watcher as if it came from the filesystem.
+func (b *FakeBackend) Emit(path string, op Op) {
+	b.events <- FileEvent{Path: path, Op: op}
+}
+
+// Fail delivers an error to the Watcher.
+func (b *FakeBackend) Fail(err error) {
+	b.errors <- err
+}
+
+func (b *FakeBackend) Events() <-chan FileEvent { return b.events }
+
+func (b *FakeBackend) Errors() <-chan error { return b.errors }
+
+func (b *FakeBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.events)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+type fsnotifyBackend struct {
+	watcher *fsnotify.Watcher
+	skip    func(string) bool
+	events  chan FileEvent
+}
+
+func newFsnotifyBackend(skip func(string) bool) (*fsnotifyBackend, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &fsnotifyBackend{
+		watcher: w,
+		skip:    skip,
+		events:  make(chan FileEvent),
+	}
+	go b.loop()
+	return b, nil
+}
+
+func (b *fsnotifyBackend) Add(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if b.skip(path) {
+				return filepath.SkipDir
+			}
+			return b.watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+func (b *fsnotifyBackend) Remove(root string) error {
+	root = filepath.Clean(root)
+	for _, path := range b.watcher.WatchList() {
+		if within(filepath.Clean(path), root) {
+			b.watcher.Remove(path)
+		}
+	}
+	return nil
+}
+
+func (b *fsnotifyBackend) Events() <-chan FileEvent { return b.events }
+
+func (b *fsnotifyBackend) Errors() <-chan error { return b.watcher.Errors }
+
+func (b *fsnotifyBackend) Close() error { return b.watcher.Close() }
+
+func (b *fsnotifyBackend) loop() {
+	defer close(b.events)
+
+	for event := range b.watcher.Events {
+		var op Op
+		switch {
+		case event.Has(fsnotify.Create):
+			op = OpCreate
+			// fsnotify is not recursive, so new directories must be added by hand
+			if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() && !b.skip(event.Name) {
+				b.Add(event.Name)
+			}
+		case event.Has(fsnotify.Write):
+			op = OpWrite
+		case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+			op = OpRemove
+		default:
+			continue
+		}
+		b.events <- FileEvent{Path: event.Name, Op: op}
+	}
+}
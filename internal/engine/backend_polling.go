@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// pollingBackend detects changes by periodically walking the watched trees and
+// comparing a fingerprint of each file's mtime and size. It works on any
+// filesystem, including NFS, bind mounts and WSL drives where inotify events
+// are not delivered.
+type pollingBackend struct {
+	interval time.Duration
+	skip     func(string) bool
+	events   chan FileEvent
+	errors   chan error
+	done     chan struct{}
+	stop     sync.Once
+
+	mu    sync.Mutex
+	roots []string
+	files map[string]pollEntry
+	gen   uint32
+}
+
+type pollEntry struct {
+	fingerprint uint64
+	gen         uint32
+}
+
+func newPollingBackend(interval time.Duration, skip func(string) bool) *pollingBackend {
+	b := &pollingBackend{
+		interval: interval,
+		skip:     skip,
+		events:   make(chan FileEvent),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+		files:    make(map[string]pollEntry),
+	}
+	go b.loop()
+	return b
+}
+
+func (b *pollingBackend) Add(root string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roots = append(b.roots, root)
+	// Record the initial state without reporting it as changes
+	return b.walk(root, b.gen, nil)
+}
+
+func (b *pollingBackend) Remove(root string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	root = filepath.Clean(root)
+	roots := b.roots[:0]
+	for _, r := range b.roots {
+		if filepath.Clean(r) != root {
+			roots = append(roots, r)
+		}
+	}
+	b.roots = roots
+
+	for path := range b.files {
+		if within(filepath.Clean(path), root) {
+			delete(b.files, path)
+		}
+	}
+	return nil
+}
+
+func (b *pollingBackend) Events() <-chan FileEvent { return b.events }
+
+func (b *pollingBackend) Errors() <-chan error { return b.errors }
+
+func (b *pollingBackend) Close() error {
+	b.stop.Do(func() { close(b.done) })
+	return nil
+}
+
+func (b *pollingBackend) loop() {
+	defer close(b.events)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, event := range b.scan() {
+				select {
+				case b.events <- event:
+				case <-b.done:
+					return
+				}
+			}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// scan walks every root once and returns the changes since the previous scan.
+func (b *pollingBackend) scan() []FileEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.gen++
+	var changes []FileEvent
+	for _, root := range b.roots {
+		if err := b.walk(root, b.gen, &changes); err != nil {
+			select {
+			case b.errors <- err:
+			default:
+			}
+		}
+	}
+
+	for path, entry := range b.files {
+		if entry.gen != b.gen {
+			delete(b.files, path)
+			changes = append(changes, FileEvent{Path: path, Op: OpRemove})
+		}
+	}
+	return changes
+}
+
+func (b *pollingBackend) walk(root string, gen uint32, changes *[]FileEvent) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can disappear between listing and stat; the next scan catches up
+			if path != root {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if b.skip(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fp := uint64(info.ModTime().UnixNano())*31 + uint64(info.Size())
+
+		prev, seen := b.files[path]
+		b.files[path] = pollEntry{fingerprint: fp, gen: gen}
+		if changes == nil {
+			return nil
+		}
+		if !seen {
+			*changes = append(*changes, FileEvent{Path: path, Op: OpCreate})
+		} else if prev.fingerprint != fp {
+			*changes = append(*changes, FileEvent{Path: path, Op: OpWrite})
+		}
+		return nil
+	})
+}
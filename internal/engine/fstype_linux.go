@@ -0,0 +1,50 @@
+//go:build linux
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Filesystem magic numbers (see statfs(2)) on which inotify is unreliable.
+var remoteFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0xff534d42: "cifs",
+	0x517b:     "smb",
+	0xfe534d42: "smb2",
+	0x01021997: "9p",
+	0x65735546: "fuse",
+	0x6a656a63: "virtiofs",
+}
+
+// remoteFilesystem reports whether path lives on a network or shared
+// filesystem that does not deliver inotify events reliably.
+func remoteFilesystem(path string) (string, bool) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	if name, ok := remoteFilesystems[uint32(st.Type)]; ok {
+		return name, true
+	}
+
+	// WSL exposes Windows drives under /mnt/<drive> via drvfs
+	if isWSL() {
+		if abs, err := filepath.Abs(path); err == nil && strings.HasPrefix(abs, "/mnt/") {
+			return "drvfs", true
+		}
+	}
+	return "", false
+}
+
+func isWSL() bool {
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(data)), "microsoft")
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package engine
+
+// remoteFilesystem is only implemented on Linux; elsewhere fsnotify is used
+// unless the polling backend is selected explicitly.
+func remoteFilesystem(path string) (string, bool) {
+	return "", false
+}
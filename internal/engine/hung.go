@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HungTest describes a package that was stopped for running past its
+// timeout: which tests were still running and where its goroutines were
+// blocked, taken from the test binary's goroutine dump.
+type HungTest struct {
+	// Test is the innermost test that was running, if any.
+	Test string `json:"test,omitempty"`
+	// Running lists every test without a result when the package was
+	// stopped.
+	Running []string `json:"running,omitempty"`
+	// Timeout and Elapsed are in seconds.
+	Timeout float64 `json:"timeout"`
+	Elapsed float64 `json:"elapsed"`
+	// File, Line and State locate Test's goroutine at the deepest frame in
+	// the package, e.g. "chan receive" at h_test.go:13.
+	File  string `json:"file,omitempty"`
+	Line  int    `json:"line,omitempty"`
+	State string `json:"state,omitempty"`
+	// Goroutines are those with at least one frame in the package, Test's
+	// first.
+	Goroutines []Goroutine `json:"goroutines,omitempty"`
+}
+
+// Goroutine is one entry of a goroutine dump.
+type Goroutine struct {
+	ID     int     `json:"id"`
+	State  string  `json:"state"`
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a function call on a goroutine's stack, innermost first.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// goroutinePattern matches goroutine headers such as
+// "goroutine 6 gp=0xc000 m=nil [chan receive, 2 minutes]:".
+var goroutinePattern = regexp.MustCompile(`^goroutine (\d+)(?: [^\[]*)? \[([^\]]+)\]:$`)
+
+// ParseGoroutines extracts the goroutines of a goroutine dump, as printed
+// on a panic, a test timeout or SIGQUIT. Other lines are ignored.
+func ParseGoroutines(lines []string) []Goroutine {
+	var goroutines []Goroutine
+	var current *Goroutine
+	function := ""
+	for _, text := range lines {
+		if m := goroutinePattern.FindStringSubmatch(text); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			goroutines = append(goroutines, Goroutine{ID: id, State: m[2]})
+			current, function = &goroutines[len(goroutines)-1], ""
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch {
+		case text == "" || strings.HasPrefix(text, "created by "):
+			// The creator is not on the goroutine's stack
+			function = ""
+		case strings.HasPrefix(text, "\t"):
+			m := framePattern.FindStringSubmatch(text)
+			if m == nil || function == "" {
+				continue
+			}
+			line, _ := strconv.Atoi(m[2])
+			current.Frames = append(current.Frames, Frame{Function: function, File: m[1], Line: line})
+			function = ""
+		default:
+			function = text
+			if i := strings.LastIndex(text, "("); i > 0 && strings.HasSuffix(text, ")") {
+				function = text[:i]
+			}
+		}
+	}
+	return goroutines
+}
+
+// inPackage reports whether a function belongs to the package pkg or its
+// external test package.
+func (f Frame) inPackage(pkg string) bool {
+	return strings.HasPrefix(f.Function, pkg+".") || strings.HasPrefix(f.Function, pkg+"_test.")
+}
+
+// inPackage reports whether any of the goroutine's frames is in pkg.
+func (g Goroutine) inPackage(pkg string) bool {
+	for _, frame := range g.Frames {
+		if frame.inPackage(pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// testFunction returns the function testing.tRunner calls on the goroutine
+// if it belongs to the top-level test, or "".
+func (g Goroutine) testFunction(pkg, test string) string {
+	if test == "" {
+		return ""
+	}
+	for i := 1; i < len(g.Frames); i++ {
+		if g.Frames[i].Function == "testing.tRunner" && g.Frames[i-1].runs(pkg, test) {
+			return g.Frames[i-1].Function
+		}
+	}
+	return ""
+}
+
+// runs reports whether a function is the test function of the top-level
+// test, or a closure inside it.
+func (f Frame) runs(pkg, test string) bool {
+	for _, prefix := range []string{pkg + ".", pkg + "_test."} {
+		if rest, ok := strings.CutPrefix(f.Function, prefix+test); ok && (rest == "" || rest[0] == '.') {
+			return true
+		}
+	}
+	return false
+}
+
+// newHungTest builds the report of a stopped package from the tests still
+// running and the goroutine dump. timeout and elapsed are in seconds.
+func newHungTest(pkg string, running []string, dump []string, timeout, elapsed float64) *HungTest {
+	sort.Strings(running)
+	hung := &HungTest{Running: running, Timeout: timeout, Elapsed: elapsed}
+	// Subtests sort after their parent, so the innermost one is the last
+	// test that is not the parent of the next
+	for i, name := range running {
+		if i+1 == len(running) || !strings.HasPrefix(running[i+1], name+"/") {
+			hung.Test = name
+			break
+		}
+	}
+
+	top, _, _ := strings.Cut(hung.Test, "/")
+	var test *Goroutine
+	var others []Goroutine
+	depth := 0
+	for _, g := range ParseGoroutines(dump) {
+		if !g.inPackage(pkg) {
+			continue
+		}
+		// A test's goroutine has its function, or for a subtest a closure
+		// inside it, called by testing.tRunner; the longest name is the
+		// innermost subtest
+		if fn := g.testFunction(pkg, top); len(fn) > depth {
+			if test != nil {
+				others = append(others, *test)
+			}
+			g := g
+			test, depth = &g, len(fn)
+			continue
+		}
+		others = append(others, g)
+	}
+	if test != nil {
+		for _, frame := range test.Frames {
+			if frame.inPackage(pkg) {
+				hung.File, hung.Line, hung.State = frame.File, frame.Line, test.State
+				break
+			}
+		}
+		hung.Goroutines = append(hung.Goroutines, *test)
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].ID < others[j].ID })
+	hung.Goroutines = append(hung.Goroutines, others...)
+	return hung
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// Ingest builds a result from a "go test -json" log written elsewhere, e.g.
+// in CI or on another machine, the way RunPackages does from a live run.
+// Lines that are not events, such as compiler errors of older go versions,
+// are read as build output. The result is timestamped with the first event
+// that has a time, if any does.
+func (r *Runner) Ingest(log io.Reader) (*TestResult, error) {
+	result := &TestResult{
+		Packages: make(map[string]*PackageResult),
+		Success:  true,
+	}
+
+	var other bytes.Buffer
+	events := 0
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event GoTestEvent
+		if err := json.Unmarshal(line, &event); err != nil || event.Action == "" {
+			other.Write(line)
+			other.WriteByte('\n')
+			continue
+		}
+		// Packages that fail to build start with build-output events,
+		// which have no time
+		if result.Timestamp.IsZero() {
+			result.Timestamp = event.Time
+		}
+		events++
+		r.processEvent(result, event)
+		if r.Progress != nil {
+			r.Progress(event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if events == 0 {
+		return nil, errors.New("no go test -json events found")
+	}
+	if result.Timestamp.IsZero() {
+		result.Timestamp = time.Now()
+	}
+	addBuildErrors(result, &other)
+
+	// A log cut short, e.g. by a CI job's timeout, leaves packages without
+	// a result; fail them as go test would have
+	for name, pkg := range result.Packages {
+		if pkg.Status == "" {
+			r.processEvent(result, GoTestEvent{Action: "fail", Package: name})
+		}
+	}
+	if result.FailedTests > 0 {
+		result.Success = false
+	}
+	return result, nil
+}
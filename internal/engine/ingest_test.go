@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIngestBuildFailure(t *testing.T) {
+	log := strings.Join([]string{
+		`{"ImportPath":"example.com/m/a [example.com/m/a.test]","Action":"build-output","Output":"# example.com/m/a [example.com/m/a.test]\n"}`,
+		`{"ImportPath":"example.com/m/a [example.com/m/a.test]","Action":"build-output","Output":"a/a_test.go:5:2: undefined: missing\n"}`,
+		`{"ImportPath":"example.com/m/a [example.com/m/a.test]","Action":"build-fail"}`,
+		`{"Time":"2026-10-19T12:00:00Z","Action":"start","Package":"example.com/m/a"}`,
+		`{"Time":"2026-10-19T12:00:00.1Z","Action":"output","Package":"example.com/m/a","Output":"FAIL\texample.com/m/a [build failed]\n"}`,
+		`{"Time":"2026-10-19T12:00:00.1Z","Action":"fail","Package":"example.com/m/a","Elapsed":0,"FailedBuild":"example.com/m/a [example.com/m/a.test]"}`,
+		`{"Time":"2026-10-19T12:00:01Z","Action":"start","Package":"example.com/m/b"}`,
+		`{"Time":"2026-10-19T12:00:01Z","Action":"run","Package":"example.com/m/b","Test":"TestCut"}`,
+	}, "\n")
+
+	result, err := NewRunner().Ingest(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC); !result.Timestamp.Equal(want) {
+		t.Errorf("timestamp = %s, want the first event time %s", result.Timestamp, want)
+	}
+	if status := result.Packages["example.com/m/a"].Status; status != StatusBuildFailed {
+		t.Errorf("a status = %s, want %s", status, StatusBuildFailed)
+	}
+	// The log ends while b is still running
+	if b := result.Packages["example.com/m/b"]; !b.Failed() {
+		t.Errorf("b status = %s, want it failed", b.Status)
+	}
+	if result.Success {
+		t.Error("result succeeded")
+	}
+}
+
+func TestIngestWithoutEvents(t *testing.T) {
+	if _, err := NewRunner().Ingest(strings.NewReader("ok  \texample.com/m\t0.01s\n")); err == nil {
+		t.Error("want an error for a log without events")
+	}
+}
@@ -0,0 +1,262 @@
+package engine
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Module is a Go module found in the project, either listed in go.work or
+// discovered as a nested go.mod.
+type Module struct {
+	Path string `json:"path"` // Module path from go.mod
+	Dir  string `json:"dir"`  // Directory relative to the project root
+}
+
+// DiscoverModules finds every module under root. Modules listed in a go.work
+// file are included even when they live outside of root. Ignored paths,
+// vendor and testdata directories are not searched.
+func DiscoverModules(root string, ignore []string) ([]Module, error) {
+	dirs := make(map[string]bool)
+
+	if uses, err := parseGoWork(filepath.Join(root, "go.work")); err == nil {
+		for _, use := range uses {
+			dirs[filepath.Clean(filepath.Join(root, use))] = true
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (ignored(path, ignore) || name == "vendor" || name == "testdata" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "go.mod" {
+			dirs[filepath.Dir(path)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []Module
+	for dir := range dirs {
+		modPath, err := readModulePath(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			continue
+		}
+		modules = append(modules, Module{Path: modPath, Dir: dir})
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Dir < modules[j].Dir })
+	return modules, nil
+}
+
+// ModuleForDir returns the innermost module containing dir, or nil.
+func ModuleForDir(modules []Module, dir string) *Module {
+	dir = filepath.Clean(dir)
+	var owner *Module
+	for i := range modules {
+		if within(dir, modules[i].Dir) && (owner == nil || len(modules[i].Dir) > len(owner.Dir)) {
+			owner = &modules[i]
+		}
+	}
+	return owner
+}
+
+// within reports whether path is base itself or lies beneath it.
+func within(path, base string) bool {
+	if base == "." {
+		return !strings.HasPrefix(path, "..") && !filepath.IsAbs(path)
+	}
+	return path == base || strings.HasPrefix(path, base+string(filepath.Separator))
+}
+
+func parseGoWork(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var uses []string
+	inBlock := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			uses = append(uses, unquote(line))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			uses = append(uses, unquote(strings.TrimSpace(strings.TrimPrefix(line, "use "))))
+		}
+	}
+	return uses, scanner.Err()
+}
+
+func readModulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return unquote(strings.TrimSpace(strings.TrimPrefix(line, "module "))), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", os.ErrNotExist
+}
+
+func stripComment(line string) string {
+	if i := strings.Index(line, "//"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(line)
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, "\"`")
+}
+
+type moduleGroup struct {
+	module   Module
+	patterns []string
+}
+
+// planModules splits package patterns relative to the current directory into
+// per-module patterns relative to each module's directory. A recursive
+// pattern also covers every nested module beneath it.
+func planModules(modules []Module, pkgs []string) []moduleGroup {
+	patterns := make(map[string][]string)
+	add := func(m *Module, pattern string) {
+		for _, p := range patterns[m.Dir] {
+			if p == pattern {
+				return
+			}
+		}
+		patterns[m.Dir] = append(patterns[m.Dir], pattern)
+	}
+
+	for _, pkg := range pkgs {
+		if !strings.HasPrefix(pkg, ".") {
+			// Import path: owned by the module with the longest matching path
+			var owner *Module
+			for i := range modules {
+				if (pkg == modules[i].Path || strings.HasPrefix(pkg, modules[i].Path+"/")) &&
+					(owner == nil || len(modules[i].Path) > len(owner.Path)) {
+					owner = &modules[i]
+				}
+			}
+			if owner != nil {
+				add(owner, pkg)
+			}
+			continue
+		}
+
+		base := strings.TrimSuffix(pkg, "/...")
+		recursive := base != pkg
+		base = relPath(base)
+
+		owner := ModuleForDir(modules, base)
+		if owner != nil {
+			rel, _ := filepath.Rel(owner.Dir, base)
+			pattern := "./" + filepath.ToSlash(rel)
+			if rel == "." {
+				pattern = "."
+			}
+			if recursive {
+				pattern = strings.TrimSuffix(pattern, "/.") + "/..."
+			}
+			add(owner, pattern)
+		}
+
+		if recursive {
+			for i := range modules {
+				if (owner == nil || modules[i].Dir != owner.Dir) && (base == "." || within(modules[i].Dir, base)) {
+					add(&modules[i], "./...")
+				}
+			}
+		}
+	}
+
+	var groups []moduleGroup
+	for _, m := range modules {
+		if p, ok := patterns[m.Dir]; ok {
+			groups = append(groups, moduleGroup{module: m, patterns: p})
+		}
+	}
+	return groups
+}
+
+// modulePattern returns the pattern matching every package of the module in dir.
+func modulePattern(dir string) string {
+	if dir == "." {
+		return "./..."
+	}
+	return "./" + filepath.ToSlash(dir) + "/..."
+}
+
+// relPath makes path relative to the working directory where possible.
+func relPath(path string) string {
+	if filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil {
+				return rel
+			}
+		}
+	}
+	return filepath.Clean(path)
+}
+
+// ModuleDir returns the directory, relative to the project root, of the
+// module a package in result belongs to, or "" if it is unknown. Paths in
+// go command output such as BuildErrors are relative to it.
+func (r *TestResult) ModuleDir(name string) string {
+	pkg, ok := r.Packages[name]
+	if !ok {
+		return ""
+	}
+	if mod, ok := r.Modules[pkg.Module]; ok {
+		return mod.Dir
+	}
+	return ""
+}
+
+// PackageDir returns the directory of a package in result relative to the
+// project root, derived from its module, or "" if it is unknown.
+func (r *TestResult) PackageDir(name string) string {
+	pkg, ok := r.Packages[name]
+	if !ok {
+		return ""
+	}
+	mod, ok := r.Modules[pkg.Module]
+	if !ok {
+		return ""
+	}
+	if name != mod.Path && !strings.HasPrefix(name, mod.Path+"/") {
+		return ""
+	}
+	return filepath.Join(mod.Dir, strings.TrimPrefix(name, mod.Path))
+}
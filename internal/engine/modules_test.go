@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseGoWork(t *testing.T) {
+	tests := []struct {
+		name string
+		work string
+		want []string
+	}{
+		{"block", "go 1.22\n\nuse (\n\t.\n\t./tools\n\t\"./libs/other\"\n)\n", []string{".", "./tools", "./libs/other"}},
+		{"single line", "go 1.22\nuse ./tools\nuse \"./libs/other\"\n", []string{"./tools", "./libs/other"}},
+		{"comments", "// workspace\nuse ( // modules\n\t./tools // tooling\n\t// ./disabled\n)\n", []string{"./tools"}},
+		{"commented out single line", "use ./tools\n// use ./disabled\n", []string{"./tools"}},
+		{"no uses", "go 1.22\n\nreplace example.com/x => ./x\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "go.work")
+			if err := os.WriteFile(path, []byte(tt.work), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseGoWork(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseGoWork = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseGoWork(filepath.Join(t.TempDir(), "go.work")); !os.IsNotExist(err) {
+		t.Errorf("missing go.work: err = %v, want not exist", err)
+	}
+}
+
+var testModules = []Module{
+	{Path: "example.com/m", Dir: "."},
+	{Path: "example.com/m/tools", Dir: "tools"},
+	{Path: "example.com/other", Dir: filepath.Join("libs", "other")},
+}
+
+func TestPlanModules(t *testing.T) {
+	root, tools, other := testModules[0], testModules[1], testModules[2]
+	tests := []struct {
+		name string
+		pkgs []string
+		want []moduleGroup
+	}{
+		{
+			"everything",
+			[]string{"./..."},
+			[]moduleGroup{{root, []string{"./..."}}, {tools, []string{"./..."}}, {other, []string{"./..."}}},
+		},
+		{
+			"directory of the root module",
+			[]string{"./internal/..."},
+			[]moduleGroup{{root, []string{"./internal/..."}}},
+		},
+		{
+			"package in a nested module",
+			[]string{"./tools/cmd"},
+			[]moduleGroup{{tools, []string{"./cmd"}}},
+		},
+		{
+			"whole nested module",
+			[]string{"./tools/..."},
+			[]moduleGroup{{tools, []string{"./..."}}},
+		},
+		{
+			"directory holding a nested module",
+			[]string{"./libs/..."},
+			[]moduleGroup{{root, []string{"./libs/..."}}, {other, []string{"./..."}}},
+		},
+		{
+			"import paths",
+			[]string{"example.com/m/tools/cmd", "example.com/m/x", "example.com/unknown"},
+			[]moduleGroup{{root, []string{"example.com/m/x"}}, {tools, []string{"example.com/m/tools/cmd"}}},
+		},
+		{
+			"duplicates",
+			[]string{"./tools/cmd", "./tools/cmd/", "example.com/m/x", "example.com/m/x"},
+			[]moduleGroup{{root, []string{"example.com/m/x"}}, {tools, []string{"./cmd"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planModules(testModules, tt.pkgs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planModules(%q) = %+v, want %+v", tt.pkgs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleForDir(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want string // Module path, "" for none
+	}{
+		{".", "example.com/m"},
+		{"internal/engine", "example.com/m"},
+		{"tools", "example.com/m/tools"},
+		{"tools/cmd/", "example.com/m/tools"},
+		{"toolsx", "example.com/m"},
+		{"libs/other/pkg", "example.com/other"},
+		{"../elsewhere", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if m := ModuleForDir(testModules, filepath.FromSlash(tt.dir)); m != nil {
+			got = m.Path
+		}
+		if got != tt.want {
+			t.Errorf("ModuleForDir(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+
+	// Without a module at the root, directories outside the nested ones
+	// belong to none
+	if m := ModuleForDir(testModules[1:], "internal"); m != nil {
+		t.Errorf("ModuleForDir(internal) = %+v, want none", m)
+	}
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PackagesForFiles maps changed files to the relative package patterns
+// ("./internal/engine") that contain them. Non-Go files, ignored paths and
+// directories that no longer exist are dropped.
+func PackagesForFiles(files []string, ignore []string) []string {
+	seen := make(map[string]bool)
+	var pkgs []string
+	for _, file := range files {
+		file = relPath(file)
+		if !strings.HasSuffix(file, ".go") || ignored(file, ignore) {
+			continue
+		}
+		dir := filepath.Dir(file)
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			continue
+		}
+
+		pkg := "./" + filepath.ToSlash(dir)
+		if dir == "." {
+			pkg = "."
+		}
+		if !seen[pkg] {
+			seen[pkg] = true
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+func ignored(path string, ignore []string) bool {
+	for _, pattern := range ignore {
+		if strings.Contains(path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPackage reports whether an import path matches any pattern. A
+// trailing "/..." matches the package and everything below it; other
+// patterns use path.Match.
+func MatchPackage(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+			if name == prefix || strings.HasPrefix(name, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
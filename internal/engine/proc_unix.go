@@ -0,0 +1,25 @@
+//go:build !windows
+
+package engine
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in a process group of its own so that it and
+// the test binaries it spawns can be signalled together.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// quitProcessGroup sends SIGQUIT to cmd's process group, which makes test
+// binaries dump their goroutines before exiting.
+func quitProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGQUIT)
+}
+
+// killProcessGroup kills cmd and everything it spawned.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package engine
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in a process group of its own so that it and
+// the test binaries it spawns can be stopped together.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// quitProcessGroup would ask test binaries for a goroutine dump; Windows has
+// no SIGQUIT, so timed out packages are killed without one.
+func quitProcessGroup(cmd *exec.Cmd) error {
+	return errors.New("goroutine dumps are not supported on windows")
+}
+
+// killProcessGroup kills cmd and everything it spawned.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
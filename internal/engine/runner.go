@@ -2,16 +2,37 @@ package engine
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
 )
 
 type Runner struct {
 	Running bool
+	// Modules, when set, makes the runner invoke "go test" from each owning
+	// module's directory instead of the current one.
+	Modules []Module
+	// Profile adds its flags to every "go test" invocation.
+	Profile config.Profile
+	// Progress, when set, is called with every "go test" event as it
+	// arrives, e.g. to draw live progress.
+	Progress func(GoTestEvent)
+	// Stderr receives the go command's own error output; os.Stderr if nil.
+	Stderr io.Writer
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
 }
 
 func NewRunner() *Runner {
@@ -19,37 +40,94 @@ func NewRunner() *Runner {
 }
 
 func (r *Runner) RunTests(path string) (*TestResult, error) {
-	r.Running = true
-	defer func() { r.Running = false }()
-
 	// If path is a file, get directory
 	if strings.HasSuffix(path, ".go") {
 		// We usually want to run tests for the whole package or project even if one file changed,
 		// to catch regressions. For now, let's run "./..." from root or specific package.
 		// A simple strategy: always run "./..." for full coverage or run specific package.
 		// Let's default to ./... for now as per requirements "Autotest Engine".
+		// In a multi-module project that is the "./..." of the module owning the file.
+		if mod := ModuleForDir(r.Modules, relPath(filepath.Dir(path))); mod != nil {
+			return r.RunPackages(modulePattern(mod.Dir))
+		}
 		path = "./..."
 	}
 
-	cmd := exec.Command("go", "test", "-json", "-cover", path)
-	cmd.Stderr = os.Stderr // Capture stderr if needed
+	return r.RunPackages(path)
+}
+
+// RunPackages runs the tests of the given package patterns, with one
+// "go test" invocation per module involved.
+func (r *Runner) RunPackages(pkgs ...string) (*TestResult, error) {
+	r.Running = true
+	defer func() { r.Running = false }()
+
+	result := &TestResult{
+		Timestamp: time.Now(),
+		Packages:  make(map[string]*PackageResult),
+		Success:   true,
+	}
+
+	if len(r.Modules) == 0 {
+		if err := r.run("", pkgs, result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	result.Modules = make(map[string]*ModuleResult)
+	for _, group := range planModules(r.Modules, pkgs) {
+		if err := r.run(group.module.Dir, group.patterns, result); err != nil {
+			return nil, err
+		}
+
+		mod := &ModuleResult{Path: group.module.Path, Dir: group.module.Dir, Success: true}
+		for _, pkg := range result.Packages {
+			if pkg.Module == "" {
+				pkg.Module = group.module.Path
+			}
+			if pkg.Module == mod.Path {
+				mod.add(pkg)
+			}
+		}
+		result.Modules[mod.Path] = mod
+	}
+	return result, nil
+}
+
+// run executes "go test" in dir and merges its events into result.
+func (r *Runner) run(dir string, pkgs []string, result *TestResult) error {
+	args := append([]string{"test", "-json", "-cover"}, profileArgs(r.Profile)...)
+	args = append(args, pkgs...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	// Test binaries are children of the go command; a timeout stops them
+	// all through the group
+	setProcessGroup(cmd)
+	// Older go versions print compiler errors to stderr instead of
+	// "build-output" events, so keep a copy
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if r.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(r.Stderr, &stderr)
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, err
+		return err
 	}
+	r.setCmd(cmd)
+	defer r.setCmd(nil)
 
-	result := &TestResult{
-		Timestamp: time.Now(),
-		Packages:  make(map[string]*PackageResult),
-		Success:   true,
-	}
+	result.watchdog = newWatchdog(cmd, r.Profile)
+	go result.watchdog.watch()
 
 	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, 1024*1024)
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		var event GoTestEvent
@@ -57,23 +135,90 @@ func (r *Runner) RunTests(path string) (*TestResult, error) {
 			continue // Skip non-JSON lines (e.g. build output)
 		}
 
+		result.watchdog.observe(event)
 		r.processEvent(result, event)
+		if r.Progress != nil {
+			r.Progress(event)
+		}
 	}
 
 	if err := cmd.Wait(); err != nil {
 		// go test returns exit code 1 if tests fail, which is expected
 		result.Success = false
 	}
+	result.watchdog.stop()
+	addBuildErrors(result, &stderr)
+
+	// Packages cut off by a kill never reported; fail them the way go test
+	// would have
+	for name, elapsed := range result.watchdog.unfinished() {
+		event := GoTestEvent{Action: "fail", Package: name, Elapsed: elapsed.Seconds()}
+		r.processEvent(result, event)
+		if r.Progress != nil {
+			r.Progress(event)
+		}
+	}
+	result.watchdog = nil
 
 	// Determine overall success if not already set by exit code (though exit code usually covers it)
 	if result.FailedTests > 0 {
 		result.Success = false
 	}
 
-	return result, nil
+	return nil
+}
+
+// setCmd records the running "go test" for Kill.
+func (r *Runner) setCmd(cmd *exec.Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cmd = cmd
+}
+
+// Kill stops the running "go test", if any, and every test binary it
+// started. It may be called from any goroutine, e.g. on shutdown: test
+// binaries run in their own process group and do not see the terminal's
+// interrupt.
+func (r *Runner) Kill() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cmd != nil {
+		killProcessGroup(r.cmd)
+	}
+}
+
+func profileArgs(p config.Profile) []string {
+	var args []string
+	// go test's own -timeout must not fire before the watchdog does
+	if !hasFlag(p.Args, "timeout") {
+		args = append(args, "-timeout", (maxTimeout(p) + time.Minute).String())
+	}
+	if len(p.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(p.Tags, ","))
+	}
+	if p.Run != "" {
+		args = append(args, "-run", p.Run)
+	}
+	if p.Race {
+		args = append(args, "-race")
+	}
+	if p.Short {
+		args = append(args, "-short")
+	}
+	return append(args, p.Args...)
 }
 
 func (r *Runner) processEvent(result *TestResult, event GoTestEvent) {
+	switch event.Action {
+	case "build-output":
+		if result.build == nil {
+			result.build = make(map[string][]string)
+		}
+		path := importPath(event.ImportPath)
+		result.build[path] = append(result.build[path], strings.TrimSuffix(event.Output, "\n"))
+		return
+	}
+
 	// Initialize package entry if needed
 	if event.Package == "" {
 		return
@@ -89,37 +234,50 @@ func (r *Runner) processEvent(result *TestResult, event GoTestEvent) {
 
 	switch event.Action {
 	case "run":
-		// Test started
-	case "pass", "fail", "skip":
 		if event.Test != "" {
-			// This is a test case
-			testCase := &TestCase{
-				Name:     event.Test,
-				Duration: event.Elapsed,
-				Status:   strings.ToUpper(event.Action),
-			}
-			pkg.Tests = append(pkg.Tests, testCase)
-
-			result.TotalTests++
-			if event.Action == "pass" {
-				result.PassedTests++
-			} else if event.Action == "fail" {
-				result.FailedTests++
-				result.Success = false
-			} else {
-				result.SkippedTests++
+			if result.running == nil {
+				result.running = make(map[string]bool)
 			}
+			result.running[event.Package+" "+event.Test] = true
+		}
+	case "pass", "fail", "skip":
+		if event.Test != "" {
+			finishTest(result, pkg, event.Test, strings.ToUpper(event.Action), event.Elapsed)
 		} else {
 			// This is the package result
-			pkg.Status = strings.ToUpper(event.Action)
+			delete(result.panics, pkg.Name)
+			pkg.Status = packageStatus(result, pkg, event)
 			pkg.Duration = event.Elapsed
 			result.Duration += event.Elapsed
+			if pkg.Failed() {
+				result.Success = false
+			}
 		}
 	case "output":
-		// Attach output to the currently running test or package log
-		// Only logic for attaching to specific tests is complex without tracking state.
-		// For simplicity, we won't attach granular output in this initial version
-		// unless we track the 'Run' action stack.
+		if line := strings.TrimSuffix(event.Output, "\n"); pkg.Stack == nil {
+			startStack(result, pkg, line)
+		} else {
+			appendStack(result, pkg, line)
+		}
+		if event.Test == "" && strings.HasSuffix(strings.TrimSpace(event.Output), "[build failed]") {
+			if result.buildFailed == nil {
+				result.buildFailed = make(map[string]bool)
+			}
+			result.buildFailed[pkg.Name] = true
+		}
+		// Output of a test is kept until its result arrives
+		if event.Test != "" {
+			if !testFraming(event.Output) {
+				if result.output == nil {
+					result.output = make(map[string][]string)
+				}
+				key := event.Package + " " + event.Test
+				if len(result.output[key]) < maxTestOutput {
+					result.output[key] = append(result.output[key], strings.TrimSuffix(event.Output, "\n"))
+				}
+			}
+			return
+		}
 		// Check for coverage output
 		// Format: "coverage: 45.2% of statements\n"
 		if strings.Contains(event.Output, "coverage:") && strings.Contains(event.Output, "% of statements") {
@@ -136,3 +294,222 @@ func (r *Runner) processEvent(result *TestResult, event GoTestEvent) {
 		}
 	}
 }
+
+// finishTest records the result of a test.
+func finishTest(result *TestResult, pkg *PackageResult, name, status string, elapsed float64) {
+	key := pkg.Name + " " + name
+	testCase := pkg.test(name)
+	testCase.Duration = elapsed
+	testCase.Status = status
+	testCase.Output = result.output[key]
+	delete(result.output, key)
+	delete(result.running, key)
+
+	if status == "FAIL" {
+		result.Success = false
+	}
+	// Subtests finish before their parent, so by now a parent has its
+	// children
+	if !testCase.Leaf() {
+		result.ParentTests++
+		return
+	}
+	result.TotalTests++
+	switch status {
+	case "PASS":
+		result.PassedTests++
+	case "FAIL":
+		result.FailedTests++
+	default:
+		result.SkippedTests++
+	}
+}
+
+// packageStatus works out why a package finished as it did. A panic or
+// timeout kills the test binary, so tests still running at that point never
+// get a result of their own and are failed here.
+func packageStatus(result *TestResult, pkg *PackageResult, event GoTestEvent) string {
+	status := strings.ToUpper(event.Action)
+	if status != "FAIL" {
+		return status
+	}
+
+	if event.FailedBuild != "" || result.buildFailed[pkg.Name] {
+		if errors := parseBuildOutput(result.build[importPath(event.FailedBuild)]); len(errors) > 0 {
+			pkg.BuildErrors = errors
+		} else {
+			pkg.BuildErrors = parseBuildOutput(result.build[pkg.Name])
+		}
+		return StatusBuildFailed
+	}
+
+	var unfinished []string
+	for key := range result.running {
+		if name, ok := strings.CutPrefix(key, pkg.Name+" "); ok {
+			unfinished = append(unfinished, name)
+		}
+	}
+	// What was running matters for a hang, so report it before failing
+	// the tests
+	timedOut := false
+	if elapsed, ok := result.watchdog.timedOut(pkg.Name); ok {
+		timeout := PackageTimeout(result.watchdog.profile, pkg.Name)
+		pkg.Hung = newHungTest(pkg.Name, slices.Clone(unfinished), result.dump[pkg.Name], timeout.Seconds(), elapsed.Seconds())
+		timedOut = true
+	} else if len(pkg.Stack) > 0 && strings.HasPrefix(pkg.Stack[0], "panic: test timed out after ") {
+		timeout, _ := time.ParseDuration(strings.TrimPrefix(pkg.Stack[0], "panic: test timed out after "))
+		pkg.Hung = newHungTest(pkg.Name, slices.Clone(unfinished), result.dump[pkg.Name], timeout.Seconds(), event.Elapsed)
+		timedOut = true
+	}
+	delete(result.dump, pkg.Name)
+	// Subtests before their parents
+	sort.Sort(sort.Reverse(sort.StringSlice(unfinished)))
+	for _, name := range unfinished {
+		finishTest(result, pkg, name, "FAIL", event.Elapsed)
+	}
+
+	switch {
+	case timedOut:
+		return StatusTimeout
+	case len(pkg.Stack) > 0 && strings.HasPrefix(pkg.Stack[0], "SIGQUIT"):
+		// Stopped along with a package that timed out
+		return status
+	case len(pkg.Stack) > 0:
+		return StatusPanic
+	}
+	return status
+}
+
+// importPath strips the " [pkg.test]" suffix go test adds to the import
+// path of test variants.
+func importPath(s string) string {
+	path, _, _ := strings.Cut(s, " ")
+	return path
+}
+
+// parseBuildOutput extracts the file:line errors from compiler output.
+func parseBuildOutput(lines []string) []ValidationIssue {
+	var errors []ValidationIssue
+	for _, line := range lines {
+		if issue, ok := ParseIssue(line); ok {
+			errors = append(errors, issue)
+		}
+	}
+	return errors
+}
+
+// addBuildErrors collects compiler errors that older go versions print to
+// stderr as "# pkg" headers followed by the package's errors, for packages
+// that failed to build without "build-output" events.
+func addBuildErrors(result *TestResult, stderr *bytes.Buffer) {
+	var pkg string
+	output := make(map[string][]string)
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if header, ok := strings.CutPrefix(line, "# "); ok {
+			pkg = importPath(header)
+			continue
+		}
+		if pkg != "" {
+			output[pkg] = append(output[pkg], line)
+		}
+	}
+
+	for name, res := range result.Packages {
+		if res.Status == StatusBuildFailed && len(res.BuildErrors) == 0 {
+			res.BuildErrors = parseBuildOutput(output[name])
+		}
+	}
+}
+
+// summaryLine reports whether a line is part of the package summary go test
+// prints after the tests, such as "FAIL\tpkg\t0.01s" or "exit status 2".
+func summaryLine(line string) bool {
+	for _, prefix := range []string{"FAIL\t", "ok  \t", "exit status "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lines of output kept per test.
+const maxTestOutput = 500
+
+// Lines of a goroutine dump kept for the hung test report.
+const maxDumpLines = 20000
+
+// Lines allowed between a "panic: " line and the goroutine header that
+// confirms it, such as the tests go test lists when its -timeout fires.
+const maxPanicHeader = 100
+
+// startStack starts a package's stack at a "SIGQUIT: quit" line, or at a
+// "panic: " line once a goroutine header follows it, so that a test that
+// merely prints "panic: " does not make its package a PANIC. Lines in
+// between are held in result.panics until then.
+func startStack(result *TestResult, pkg *PackageResult, line string) {
+	pending, ok := result.panics[pkg.Name]
+	switch {
+	case strings.HasPrefix(line, "SIGQUIT: quit"):
+		pending = nil
+	case !ok && strings.HasPrefix(line, "panic: "):
+		if result.panics == nil {
+			result.panics = make(map[string][]string)
+		}
+		result.panics[pkg.Name] = []string{line}
+		return
+	case ok && goroutinePattern.MatchString(line):
+	case ok && len(pending) < maxPanicHeader:
+		result.panics[pkg.Name] = append(pending, line)
+		return
+	default:
+		delete(result.panics, pkg.Name)
+		return
+	}
+	delete(result.panics, pkg.Name)
+
+	pkg.Stack = []string{}
+	if result.dump == nil {
+		result.dump = make(map[string][]string)
+	}
+	result.dump[pkg.Name] = []string{}
+	for _, l := range append(pending, line) {
+		appendStack(result, pkg, l)
+	}
+}
+
+// appendStack adds a line of output to a package's stack and dump.
+func appendStack(result *TestResult, pkg *PackageResult, line string) {
+	if summaryLine(line) {
+		return
+	}
+	if len(pkg.Stack) < maxTestOutput {
+		pkg.Stack = append(pkg.Stack, line)
+	}
+	if len(result.dump[pkg.Name]) < maxDumpLines {
+		result.dump[pkg.Name] = append(result.dump[pkg.Name], line)
+	}
+}
+
+// hasFlag reports whether args set the go test flag name, as -name or
+// --name, with or without "=value".
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if arg == name || strings.HasPrefix(arg, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// testFraming reports whether a line is one of the "=== RUN" or "--- PASS"
+// markers go test prints around a test's own output.
+func testFraming(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// ingestFile builds a result from a go test -json log in testdata.
+func ingestFile(t *testing.T, name string) *TestResult {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := NewRunner().Ingest(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestBuildFailure(t *testing.T) {
+	// build_fail.json has the build-output events and FailedBuild of go
+	// 1.24 on; build_fail_stderr.json the same failure from an older go,
+	// which printed compiler errors to stderr
+	for _, name := range []string{"build_fail.json", "build_fail_stderr.json"} {
+		t.Run(name, func(t *testing.T) {
+			result := ingestFile(t, name)
+			pkg := result.Packages["example.com/fx/broken"]
+			if pkg.Status != StatusBuildFailed {
+				t.Fatalf("status = %s, want %s", pkg.Status, StatusBuildFailed)
+			}
+			want := ValidationIssue{File: "broken/broken_test.go", Line: 6, Message: "undefined: missing"}
+			if len(pkg.BuildErrors) != 1 || pkg.BuildErrors[0] != want {
+				t.Errorf("build errors = %+v, want %+v", pkg.BuildErrors, want)
+			}
+			if len(pkg.Stack) != 0 || result.Success {
+				t.Errorf("stack = %q, success = %v, want no stack and a failed run", pkg.Stack, result.Success)
+			}
+		})
+	}
+}
+
+func TestPanic(t *testing.T) {
+	result := ingestFile(t, "panic.json")
+
+	crash := result.Packages["example.com/fx/crash"]
+	if crash.Status != StatusPanic {
+		t.Fatalf("crash status = %s, want %s", crash.Status, StatusPanic)
+	}
+	if len(crash.Stack) == 0 || !strings.HasPrefix(crash.Stack[0], "panic: assignment to entry in nil map") {
+		t.Errorf("stack = %q, want it to start at the panic", crash.Stack)
+	}
+	if !strings.Contains(strings.Join(crash.Stack, "\n"), "goroutine 7 [running]:") {
+		t.Errorf("stack = %q, want the panicking goroutine", crash.Stack)
+	}
+	for _, line := range crash.Stack {
+		if summaryLine(line) {
+			t.Errorf("stack contains the summary line %q", line)
+		}
+	}
+	if file, line, ok := PanicLocation(crash.Stack); !ok || !strings.HasSuffix(file, "crash/crash_test.go") || line != 9 {
+		t.Errorf("panic location = %s:%d, want crash_test.go:9", file, line)
+	}
+	if failures := crash.Failures(); len(failures) != 1 || failures[0].Name != "TestCrash" {
+		t.Errorf("failures = %v, want TestCrash", failures)
+	}
+
+	// A test printing "panic: " without a stack following it fails as usual
+	noisy := result.Packages["example.com/fx/noisy"]
+	if noisy.Status != "FAIL" || noisy.Stack != nil {
+		t.Errorf("noisy status = %s with stack %q, want FAIL without a stack", noisy.Status, noisy.Stack)
+	}
+	if excerpt := PanicExcerpt(noisy.Tests[0].Output); excerpt != nil {
+		t.Errorf("panic excerpt of noisy output = %q, want none", excerpt)
+	}
+	if len(result.panics) != 0 {
+		t.Errorf("pending panics = %v, want none once packages finish", result.panics)
+	}
+}
@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+)
+
+// DefaultTimeout is how long a package's tests may run when the profile
+// sets no timeout, the same as go test's own default.
+const DefaultTimeout = 10 * time.Minute
+
+const (
+	// watchdogInterval is how often running packages are checked against
+	// their timeout.
+	watchdogInterval = 100 * time.Millisecond
+	// quitGrace is how long stopped test binaries get to print their
+	// goroutine dumps before everything is killed.
+	quitGrace = 5 * time.Second
+)
+
+// PackageTimeout returns how long the tests of the package with import path
+// pkg may run under a profile. The longest matching pattern of
+// PackageTimeouts wins over Timeout; DefaultTimeout applies if neither is
+// set.
+func PackageTimeout(p config.Profile, pkg string) time.Duration {
+	timeout, longest := p.Timeout, -1
+	for pattern, d := range p.PackageTimeouts {
+		if len(pattern) > longest && MatchPackage(pkg, []string{pattern}) {
+			timeout, longest = d, len(pattern)
+		}
+	}
+	if timeout <= 0 {
+		return DefaultTimeout
+	}
+	return timeout
+}
+
+// maxTimeout is the longest timeout any package may get under a profile.
+func maxTimeout(p config.Profile) time.Duration {
+	longest := PackageTimeout(p, "")
+	for _, d := range p.PackageTimeouts {
+		longest = max(longest, d)
+	}
+	return longest
+}
+
+// watchdog stops a "go test" run when one of its packages runs past its
+// timeout. The whole process group gets SIGQUIT, so test binaries dump
+// their goroutines, and is killed once the timed out packages have
+// reported, or after quitGrace.
+type watchdog struct {
+	cmd     *exec.Cmd
+	profile config.Profile
+	done    chan struct{}
+
+	mu      sync.Mutex
+	started map[string]time.Time
+	// expired holds how long each timed out package had been running
+	expired map[string]time.Duration
+	quitAt  time.Time
+	killed  bool
+}
+
+func newWatchdog(cmd *exec.Cmd, profile config.Profile) *watchdog {
+	return &watchdog{
+		cmd:     cmd,
+		profile: profile,
+		done:    make(chan struct{}),
+		started: make(map[string]time.Time),
+		expired: make(map[string]time.Duration),
+	}
+}
+
+// watch checks the running packages until stop is called.
+func (w *watchdog) watch() {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case now := <-ticker.C:
+			w.check(now)
+		}
+	}
+}
+
+func (w *watchdog) stop() {
+	close(w.done)
+}
+
+func (w *watchdog) check(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.killed {
+		return
+	}
+	if !w.quitAt.IsZero() {
+		if now.Sub(w.quitAt) > quitGrace {
+			w.kill()
+		}
+		return
+	}
+
+	for pkg, started := range w.started {
+		if started.IsZero() {
+			continue // Finished
+		}
+		if elapsed := now.Sub(started); elapsed > PackageTimeout(w.profile, pkg) {
+			w.expired[pkg] = elapsed
+		}
+	}
+	if len(w.expired) == 0 {
+		return
+	}
+	w.quitAt = now
+	if err := quitProcessGroup(w.cmd); err != nil {
+		w.kill()
+	}
+}
+
+// observe tracks when packages start and finish.
+func (w *watchdog) observe(event GoTestEvent) {
+	if event.Package == "" {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.started[event.Package]; !ok {
+		// The clock starts when go test stamped the "start" event, just
+		// before running the test binary, however late the event is read.
+		// Older go versions have no "start" event, so the first event
+		// stands in for it.
+		started := time.Now()
+		if !event.Time.IsZero() && event.Time.Before(started) {
+			started = event.Time
+		}
+		w.started[event.Package] = started
+	}
+	if event.Test != "" || (event.Action != "pass" && event.Action != "fail" && event.Action != "skip") {
+		return
+	}
+	w.started[event.Package] = time.Time{}
+	// Kill as soon as every timed out package has printed its dump
+	for pkg := range w.expired {
+		if !w.started[pkg].IsZero() {
+			return
+		}
+	}
+	if len(w.expired) > 0 && !w.killed {
+		w.kill()
+	}
+}
+
+// kill must be called with mu held.
+func (w *watchdog) kill() {
+	w.killed = true
+	killProcessGroup(w.cmd)
+}
+
+// timedOut reports whether pkg was stopped for running past its timeout,
+// and how long it had been running then.
+func (w *watchdog) timedOut(pkg string) (time.Duration, bool) {
+	if w == nil {
+		return 0, false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	elapsed, ok := w.expired[pkg]
+	return elapsed, ok
+}
+
+// unfinished returns the packages the kill cut off before they reported,
+// with how long each had been running.
+func (w *watchdog) unfinished() map[string]time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.killed {
+		return nil
+	}
+	cut := make(map[string]time.Duration)
+	for pkg, started := range w.started {
+		if !started.IsZero() {
+			cut[pkg] = time.Since(started)
+		}
+	}
+	return cut
+}
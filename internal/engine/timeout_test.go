@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+)
+
+func TestPackageTimeout(t *testing.T) {
+	profile := config.Profile{
+		Timeout: 2 * time.Minute,
+		PackageTimeouts: map[string]time.Duration{
+			"example.com/app/...":                5 * time.Minute,
+			"example.com/app/internal/db/...":    10 * time.Minute,
+			"example.com/app/internal/db/bulk":   20 * time.Minute,
+			"example.com/app/cmd/*":              time.Minute,
+			"example.com/app/internal/dbx/extra": 30 * time.Minute,
+		},
+	}
+	tests := []struct {
+		pkg  string
+		want time.Duration
+	}{
+		{"example.com/other", 2 * time.Minute},
+		{"example.com/app", 5 * time.Minute},
+		{"example.com/app/internal/api", 5 * time.Minute},
+		{"example.com/app/internal/db", 10 * time.Minute},
+		{"example.com/app/internal/db/migrate", 10 * time.Minute},
+		{"example.com/app/internal/db/bulk", 20 * time.Minute},
+		{"example.com/app/internal/dbx", 5 * time.Minute},
+		{"example.com/app/cmd/server", time.Minute},
+		{"example.com/app/cmd/server/internal", 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := PackageTimeout(profile, tt.pkg); got != tt.want {
+			t.Errorf("PackageTimeout(%s) = %s, want %s", tt.pkg, got, tt.want)
+		}
+	}
+
+	if got := PackageTimeout(config.Profile{}, "example.com/app"); got != DefaultTimeout {
+		t.Errorf("PackageTimeout without timeouts = %s, want %s", got, DefaultTimeout)
+	}
+	if got := maxTimeout(profile); got != 30*time.Minute {
+		t.Errorf("maxTimeout = %s, want 30m", got)
+	}
+}
+
+func TestWatchdogClockStartsAtStartEvent(t *testing.T) {
+	w := newWatchdog(nil, config.Profile{Timeout: time.Minute})
+	started := time.Now().Add(-50 * time.Second)
+	// Read late, e.g. after a slow progress callback
+	w.observe(GoTestEvent{Time: started, Action: "start", Package: "a"})
+	w.observe(GoTestEvent{Time: started.Add(time.Second), Action: "run", Package: "a", Test: "TestA"})
+	// Without a time, as from older go versions, the clock starts when read
+	before := time.Now()
+	w.observe(GoTestEvent{Action: "run", Package: "b", Test: "TestB"})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.started["a"].Equal(started) {
+		t.Errorf("a started at %s, want the start event's time %s", w.started["a"], started)
+	}
+	if b := w.started["b"]; b.Before(before) || b.After(time.Now()) {
+		t.Errorf("b started at %s, want when its first event was read", b)
+	}
+}
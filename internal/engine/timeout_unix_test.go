@@ -0,0 +1,67 @@
+//go:build !windows
+
+package engine
+
+import (
+	"bufio"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+)
+
+func TestWatchdogQuitsThenKills(t *testing.T) {
+	// Stands in for a test binary that ignores SIGQUIT instead of dumping
+	// its goroutines and exiting
+	cmd := exec.Command("sh", "-c", "trap '' QUIT; echo ready; sleep 30")
+	setProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skip("cannot start sh:", err)
+	}
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	t.Cleanup(func() { killProcessGroup(cmd) })
+
+	w := newWatchdog(cmd, config.Profile{Timeout: time.Second})
+	start := time.Now()
+	w.observe(GoTestEvent{Time: start, Action: "start", Package: "a"})
+	w.observe(GoTestEvent{Time: start, Action: "start", Package: "b"})
+	w.observe(GoTestEvent{Time: start, Action: "pass", Package: "b"})
+
+	w.check(start.Add(500 * time.Millisecond))
+	if _, ok := w.timedOut("a"); ok {
+		t.Fatal("a timed out before its timeout")
+	}
+
+	w.check(start.Add(2 * time.Second))
+	if elapsed, ok := w.timedOut("a"); !ok || elapsed != 2*time.Second {
+		t.Fatalf("a timed out = %v after %s, want true after 2s", ok, elapsed)
+	}
+	if _, ok := w.timedOut("b"); ok {
+		t.Error("b timed out after it finished")
+	}
+	select {
+	case err := <-exited:
+		t.Fatalf("process exited on SIGQUIT (%v), want it ignored", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Still running after the grace period
+	w.check(start.Add(2*time.Second + quitGrace + time.Second))
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("process not killed after the grace period")
+	}
+	if cut := w.unfinished(); len(cut) != 1 || cut["a"] == 0 {
+		t.Errorf("unfinished = %v, want a", cut)
+	}
+}
@@ -1,6 +1,13 @@
 package engine
 
-import "time"
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // GoTestEvent represents a line of output from 'go test -json'
 type GoTestEvent struct {
@@ -10,32 +17,269 @@ type GoTestEvent struct {
 	Test    string    `json:"Test,omitempty"`
 	Output  string    `json:"Output,omitempty"`
 	Elapsed float64   `json:"Elapsed,omitempty"`
+	// ImportPath identifies the package of "build-output" and "build-fail"
+	// events, e.g. "example.com/pkg [example.com/pkg.test]".
+	ImportPath string `json:"ImportPath,omitempty"`
+	// FailedBuild is set on a package's "fail" event when it failed because
+	// this package did not compile.
+	FailedBuild string `json:"FailedBuild,omitempty"`
 }
 
-// TestResult represents the aggregated result of a test run
+// TestResult represents the aggregated result of a test run. The test
+// counts are of leaf tests; tests with subtests are counted in ParentTests.
 type TestResult struct {
 	Timestamp    time.Time                 `json:"timestamp"`
 	TotalTests   int                       `json:"total_tests"`
 	PassedTests  int                       `json:"passed_tests"`
 	FailedTests  int                       `json:"failed_tests"`
 	SkippedTests int                       `json:"skipped_tests"`
+	ParentTests  int                       `json:"parent_tests"`
 	Duration     float64                   `json:"duration"`
 	Packages     map[string]*PackageResult `json:"packages"`
 	Success      bool                      `json:"success"`
 	Issues       []string                  `json:"issues"`
+	Modules      map[string]*ModuleResult  `json:"modules,omitempty"`
+
+	// output buffers test output until the test's result arrives
+	output map[string][]string
+	// running holds the "pkg test" keys of tests without a result yet
+	running map[string]bool
+	// build holds compiler output by package import path
+	build map[string][]string
+	// buildFailed holds the packages go test reported as "[build failed]"
+	buildFailed map[string]bool
+	// dump holds the goroutine dump of packages that panicked or were
+	// stopped, uncapped by maxTestOutput
+	dump map[string][]string
+	// panics holds output from a "panic: " line on, by package, until a
+	// goroutine header shows it starts a stack
+	panics map[string][]string
+	// watchdog stops packages that run past their timeout
+	watchdog *watchdog
+}
+
+// ModuleResult aggregates the packages of one module in a multi-module run.
+type ModuleResult struct {
+	Path         string  `json:"path"`
+	Dir          string  `json:"dir"`
+	Packages     int     `json:"packages"`
+	PassedTests  int     `json:"passed_tests"`
+	FailedTests  int     `json:"failed_tests"`
+	SkippedTests int     `json:"skipped_tests"`
+	Duration     float64 `json:"duration"`
+	Success      bool    `json:"success"`
+}
+
+func (m *ModuleResult) add(pkg *PackageResult) {
+	m.Packages++
+	m.Duration += pkg.Duration
+	if pkg.Failed() {
+		m.Success = false
+	}
+	for _, test := range pkg.Leaves() {
+		switch test.Status {
+		case "PASS":
+			m.PassedTests++
+		case "FAIL":
+			m.FailedTests++
+		case "SKIP":
+			m.SkippedTests++
+		}
+	}
 }
 
+// Package statuses besides go test's PASS, FAIL and SKIP.
+const (
+	// StatusBuildFailed is a package whose tests did not compile.
+	StatusBuildFailed = "BUILD_FAIL"
+	// StatusPanic is a package whose test binary panicked.
+	StatusPanic = "PANIC"
+	// StatusTimeout is a package whose tests exceeded the profile's
+	// timeout or go test's -timeout.
+	StatusTimeout = "TIMEOUT"
+)
+
 type PackageResult struct {
-	Name     string      `json:"name"`
-	Duration float64     `json:"duration"`
-	Status   string      `json:"status"` // PASS, FAIL
+	Name     string  `json:"name"`
+	Module   string  `json:"module,omitempty"`
+	Duration float64 `json:"duration"`
+	Status   string  `json:"status"` // PASS, FAIL, SKIP, BUILD_FAIL, PANIC, TIMEOUT
+	// Tests are the top-level tests; subtests hang below their parents.
 	Tests    []*TestCase `json:"tests"`
 	Coverage float64     `json:"coverage"`
+	// BuildErrors are the compiler errors of a BUILD_FAIL package. Files
+	// are relative to the directory go test ran in.
+	BuildErrors []ValidationIssue `json:"build_errors,omitempty"`
+	// Stack is the panic message and goroutine dump of a PANIC or TIMEOUT
+	// package.
+	Stack []string `json:"stack,omitempty"`
+	// Hung describes what a TIMEOUT package was doing when it was stopped.
+	Hung *HungTest `json:"hung,omitempty"`
+}
+
+// Failed reports whether the package failed in any way.
+func (p *PackageResult) Failed() bool {
+	switch p.Status {
+	case "PASS", "SKIP", "":
+		return false
+	}
+	return true
 }
 
+// TestCase is a test or subtest. Name is the full "TestFoo/case_1" name as
+// accepted by -run.
 type TestCase struct {
-	Name     string   `json:"name"`
-	Duration float64  `json:"duration"`
-	Status   string   `json:"status"` // PASS, FAIL, SKIP
-	Output   []string `json:"output"`
+	Name     string      `json:"name"`
+	Duration float64     `json:"duration"`
+	Status   string      `json:"status"` // PASS, FAIL, SKIP
+	Output   []string    `json:"output"`
+	Subtests []*TestCase `json:"subtests,omitempty"`
+}
+
+// Leaf reports whether the test has no subtests.
+func (t *TestCase) Leaf() bool {
+	return len(t.Subtests) == 0
+}
+
+// ShortName is the last element of the test's name, e.g. "case_1".
+func (t *TestCase) ShortName() string {
+	return t.Name[strings.LastIndex(t.Name, "/")+1:]
+}
+
+// Depth is 0 for a top-level test, 1 for its subtests and so on.
+func (t *TestCase) Depth() int {
+	return strings.Count(t.Name, "/")
+}
+
+// AllTests returns every test of the package sorted by name, each parent
+// before its subtests.
+func (p *PackageResult) AllTests() []*TestCase {
+	var tests []*TestCase
+	var walk func([]*TestCase)
+	walk = func(level []*TestCase) {
+		level = append([]*TestCase(nil), level...)
+		sort.Slice(level, func(i, j int) bool { return level[i].Name < level[j].Name })
+		for _, test := range level {
+			tests = append(tests, test)
+			walk(test.Subtests)
+		}
+	}
+	walk(p.Tests)
+	return tests
+}
+
+// Leaves returns the tests of the package that have no subtests.
+func (p *PackageResult) Leaves() []*TestCase {
+	var leaves []*TestCase
+	for _, test := range p.AllTests() {
+		if test.Leaf() {
+			leaves = append(leaves, test)
+		}
+	}
+	return leaves
+}
+
+// Failures returns the tests that failed themselves rather than only
+// through a failing subtest.
+func (p *PackageResult) Failures() []*TestCase {
+	var failed []*TestCase
+	for _, test := range p.AllTests() {
+		if test.Status == "FAIL" && !test.failingSubtest() {
+			failed = append(failed, test)
+		}
+	}
+	return failed
+}
+
+func (t *TestCase) failingSubtest() bool {
+	for _, sub := range t.Subtests {
+		if sub.Status == "FAIL" {
+			return true
+		}
+	}
+	return false
+}
+
+// test returns the test with the given full name, creating it and any
+// missing parents. Parents created for a subtest get their status when
+// their own result arrives, which go test sends after the subtests'.
+func (p *PackageResult) test(name string) *TestCase {
+	level := &p.Tests
+	var test *TestCase
+	for i := 0; i <= len(name); i++ {
+		if i < len(name) && name[i] != '/' {
+			continue
+		}
+		test = nil
+		for _, t := range *level {
+			if t.Name == name[:i] {
+				test = t
+				break
+			}
+		}
+		if test == nil {
+			test = &TestCase{Name: name[:i]}
+			*level = append(*level, test)
+		}
+		level = &test.Subtests
+	}
+	return test
+}
+
+// locationPattern matches the "file_test.go:42: message" prefix t.Error and
+// friends put on test output.
+var locationPattern = regexp.MustCompile(`^\s*([\w.\-/]+\.go):(\d+):`)
+
+// framePattern matches the "\t/abs/path/file.go:42 +0x28" lines of a stack
+// trace, which SIGQUIT dumps follow with the frame's registers.
+var framePattern = regexp.MustCompile(`^\t(.+\.go):(\d+)(?: .*)?$`)
+
+// Location returns the file and line of the last message with a location in
+// the test's output, which for a failing test is usually the failure (t.Log
+// and t.Error lines look alike). The file is relative to the package
+// directory. For a panic it is the absolute path of the frame that
+// panicked. ok is false if the output names no location.
+func (t *TestCase) Location() (file string, line int, ok bool) {
+	if file, line, ok := PanicLocation(t.Output); ok {
+		return file, line, true
+	}
+	for i := len(t.Output) - 1; i >= 0; i-- {
+		if m := locationPattern.FindStringSubmatch(t.Output[i]); m != nil {
+			line, _ = strconv.Atoi(m[2])
+			return m[1], line, true
+		}
+	}
+	return "", 0, false
+}
+
+// PanicLocation finds the frame that called panic in a goroutine dump: the
+// first one after the runtime's own "panic(...)" frame.
+func PanicLocation(lines []string) (file string, line int, ok bool) {
+	inPanic := false
+	for i, text := range lines {
+		if strings.HasPrefix(text, "panic(") {
+			inPanic = true
+			continue
+		}
+		if !inPanic || i == 0 || strings.HasPrefix(lines[i-1], "panic(") {
+			continue
+		}
+		if m := framePattern.FindStringSubmatch(text); m != nil {
+			line, _ = strconv.Atoi(m[2])
+			return m[1], line, true
+		}
+	}
+	return "", 0, false
+}
+
+// PanicExcerpt returns output from its "panic:" line on, which is what
+// matters in a crash, or nil if the output has no panic: a "panic:" line
+// followed by a goroutine's stack.
+func PanicExcerpt(lines []string) []string {
+	for i, text := range lines {
+		if strings.HasPrefix(text, "panic: ") && slices.ContainsFunc(lines[i+1:], goroutinePattern.MatchString) {
+			return lines[i:]
+		}
+	}
+	return nil
 }
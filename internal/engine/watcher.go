@@ -2,82 +2,152 @@ package engine
 
 import (
 	"log"
-	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/ismailtsdln/DevTestrider/internal/config"
 )
 
 type Watcher struct {
-	watcher *fsnotify.Watcher
+	backend Backend
 	config  config.WatchConfig
 	Events  chan string
-	done    chan bool
-	mu      sync.Mutex
+	// ConfigEvents receives the path of the config file registered with
+	// WatchFile whenever it is edited.
+	ConfigEvents chan string
+	configFile   string
+	done         chan bool
+	mu           sync.Mutex
 }
 
 func NewWatcher(cfg config.WatchConfig) (*Watcher, error) {
-	w, err := fsnotify.NewWatcher()
+	w := newWatcher(cfg, nil)
+
+	b, err := newBackend(cfg, w.shouldIgnore)
 	if err != nil {
 		return nil, err
 	}
+	w.backend = b
+	return w, nil
+}
 
+// NewWatcherWithBackend creates a Watcher driven by the given Backend,
+// e.g. a fake one in tests.
+func NewWatcherWithBackend(cfg config.WatchConfig, b Backend) *Watcher {
+	return newWatcher(cfg, b)
+}
+
+func newWatcher(cfg config.WatchConfig, b Backend) *Watcher {
 	return &Watcher{
-		watcher: w,
-		config:  cfg,
-		Events:  make(chan string),
-		done:    make(chan bool),
-	}, nil
+		backend:      b,
+		config:       cfg,
+		Events:       make(chan string),
+		ConfigEvents: make(chan string),
+		done:         make(chan bool),
+	}
+}
+
+// WatchFile reports edits to the config file at path on ConfigEvents. If the
+// file is outside the watched paths, its directory is watched as well.
+func (w *Watcher) WatchFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.configFile = abs
+	paths := w.config.Paths
+	w.mu.Unlock()
+
+	for _, p := range paths {
+		if root, err := filepath.Abs(p); err == nil && within(abs, root) {
+			return nil
+		}
+	}
+	return w.backend.Add(filepath.Dir(abs))
+}
+
+// Reload applies new watch paths and ignore patterns without restarting.
+// The backend and poll interval are fixed for the lifetime of the Watcher.
+// When the ignore patterns change, the paths kept are walked again so that
+// directories no longer ignored are watched.
+func (w *Watcher) Reload(cfg config.WatchConfig) error {
+	w.mu.Lock()
+	old := w.config.Paths
+	rewalk := !slices.Equal(w.config.Ignore, cfg.Ignore)
+	w.config.Paths = cfg.Paths
+	w.config.Ignore = cfg.Ignore
+	w.mu.Unlock()
+
+	for _, path := range old {
+		if !containsPath(cfg.Paths, path) {
+			if err := w.backend.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, path := range cfg.Paths {
+		if containsPath(old, path) {
+			if !rewalk {
+				continue
+			}
+			if err := w.backend.Remove(path); err != nil {
+				return err
+			}
+		}
+		if err := w.backend.Add(path); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (w *Watcher) Start() {
-	defer w.watcher.Close()
+	defer w.backend.Close()
 
-	if err := w.addPaths(w.config.Paths); err != nil {
+	w.mu.Lock()
+	paths := w.config.Paths
+	w.mu.Unlock()
+	if err := w.addPaths(paths); err != nil {
 		log.Printf("Error adding paths: %v", err)
 	}
 
-	var debounceTimer *time.Timer
+	var debounceTimer, configTimer *time.Timer
 	const debounceDuration = 500 * time.Millisecond
 
 	for {
 		select {
-		case event, ok := <-w.watcher.Events:
+		case event, ok := <-w.backend.Events():
 			if !ok {
 				return
 			}
 
-			// Handle file operations
-			if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create || event.Op&fsnotify.Remove == fsnotify.Remove {
-
-				// Handle new directory creation regardless of name (unless ignored)
-				if event.Op&fsnotify.Create == fsnotify.Create {
-					fi, err := os.Stat(event.Name)
-					if err == nil && fi.IsDir() && !w.shouldIgnore(event.Name) {
-						if err := w.watcher.Add(event.Name); err == nil {
-							log.Printf("Watching new directory: %s", event.Name)
-						}
-						// Don't return, as we might want to trigger a test run? Maybe not for just a dir create.
-					}
+			if w.isConfigFile(event.Path) {
+				if configTimer != nil {
+					configTimer.Stop()
 				}
+				configTimer = time.AfterFunc(debounceDuration, func() {
+					w.ConfigEvents <- event.Path
+				})
+				continue
+			}
 
-				// Check if it's a Go file or relevant file for triggering tests
-				if !w.shouldIgnore(event.Name) && strings.HasSuffix(event.Name, ".go") {
-					// Debounce logic
-					if debounceTimer != nil {
-						debounceTimer.Stop()
-					}
-					debounceTimer = time.AfterFunc(debounceDuration, func() {
-						w.Events <- event.Name
-					})
+			// Check if it's a Go file or relevant file for triggering tests
+			if !w.shouldIgnore(event.Path) && strings.HasSuffix(event.Path, ".go") {
+				// Debounce logic
+				if debounceTimer != nil {
+					debounceTimer.Stop()
 				}
+				debounceTimer = time.AfterFunc(debounceDuration, func() {
+					w.Events <- event.Path
+				})
 			}
 
-		case err, ok := <-w.watcher.Errors:
+		case err, ok := <-w.backend.Errors():
 			if !ok {
 				return
 			}
@@ -94,19 +164,7 @@ func (w *Watcher) Stop() {
 
 func (w *Watcher) addPaths(paths []string) error {
 	for _, path := range paths {
-		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				if w.shouldIgnore(path) {
-					return filepath.SkipDir
-				}
-				return w.watcher.Add(path)
-			}
-			return nil
-		})
-		if err != nil {
+		if err := w.backend.Add(path); err != nil {
 			return err
 		}
 	}
@@ -114,8 +172,24 @@ func (w *Watcher) addPaths(paths []string) error {
 }
 
 func (w *Watcher) shouldIgnore(path string) bool {
-	for _, ignore := range w.config.Ignore {
-		if strings.Contains(path, ignore) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return ignored(path, w.config.Ignore)
+}
+
+func (w *Watcher) isConfigFile(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.configFile == "" {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	return err == nil && abs == w.configFile
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if filepath.Clean(p) == filepath.Clean(path) {
 			return true
 		}
 	}
@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+)
+
+// startWatcher runs a Watcher over a FakeBackend until the test ends.
+func startWatcher(t *testing.T, cfg config.WatchConfig) (*Watcher, *FakeBackend) {
+	t.Helper()
+	b := NewFakeBackend()
+	w := NewWatcherWithBackend(cfg, b)
+	done := make(chan struct{})
+	go func() {
+		w.Start()
+		close(done)
+	}()
+	t.Cleanup(func() {
+		w.Stop()
+		<-done
+	})
+	return w, b
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case path := <-ch:
+		return path
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+		return ""
+	}
+}
+
+func TestWatcherDebouncesGoChanges(t *testing.T) {
+	w, b := startWatcher(t, config.WatchConfig{Paths: []string{"."}, Ignore: []string{"vendor/"}})
+
+	b.Emit("README.md", OpWrite)
+	b.Emit("vendor/x/x.go", OpWrite)
+	b.Emit("a.go", OpWrite)
+	b.Emit("b.go", OpCreate)
+
+	if got := receive(t, w.Events); got != "b.go" {
+		t.Errorf("event = %q, want the last change b.go", got)
+	}
+	select {
+	case path := <-w.Events:
+		t.Errorf("unexpected second event %q", path)
+	case <-time.After(700 * time.Millisecond):
+	}
+}
+
+func TestWatcherConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "testrider.yml")
+	w, b := startWatcher(t, config.WatchConfig{Paths: []string{"./internal"}})
+
+	if err := w.WatchFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if roots := b.Roots(); !slices.Contains(roots, dir) {
+		t.Errorf("roots = %v, want the config file's directory %s", roots, dir)
+	}
+
+	b.Emit(file, OpWrite)
+	if got := receive(t, w.ConfigEvents); got != file {
+		t.Errorf("config event = %q, want %q", got, file)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	w, b := startWatcher(t, config.WatchConfig{Paths: []string{"./a", "./b"}})
+	// Start adds the initial paths asynchronously
+	deadline := time.Now().Add(2 * time.Second)
+	for len(b.Roots()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := w.Reload(config.WatchConfig{Paths: []string{"./b", "./c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if roots := b.Roots(); !slices.Equal(roots, []string{"./b", "./c"}) {
+		t.Errorf("roots = %v, want [./b ./c]", roots)
+	}
+}
+
+func TestWatcherReloadIgnore(t *testing.T) {
+	dir := t.TempDir()
+	gen := filepath.Join(dir, "gen")
+	if err := os.Mkdir(gen, 0755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(config.WatchConfig{Paths: []string{dir}, Ignore: []string{"gen"}, Backend: BackendFsnotify})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		w.Start()
+		close(done)
+	}()
+	t.Cleanup(func() {
+		w.Stop()
+		<-done
+	})
+
+	// Wait for the initial walk, which skips gen, by writing a file until
+	// the change is seen
+	ready := filepath.Join(dir, "ready.go")
+	deadline := time.After(5 * time.Second)
+	for seen := false; !seen; {
+		if err := os.WriteFile(ready, []byte("package ready\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-w.Events:
+			seen = true
+		case <-time.After(time.Second):
+		case <-deadline:
+			t.Fatal("watcher did not start")
+		}
+	}
+
+	// gen must be watched once it is no longer ignored
+	if err := w.Reload(config.WatchConfig{Paths: []string{dir}}); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(gen, "gen.go")
+	if err := os.WriteFile(file, []byte("package gen\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, w.Events); got != file {
+		t.Errorf("event = %q, want %q", got, file)
+	}
+}
+
+func TestPollingBackend(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newPollingBackend(10*time.Millisecond, func(string) bool { return false })
+	defer b.Close()
+	if err := b.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	next := func() FileEvent {
+		t.Helper()
+		select {
+		case event := <-b.Events():
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event")
+			return FileEvent{}
+		}
+	}
+
+	created := filepath.Join(dir, "b.go")
+	if err := os.WriteFile(created, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(); got != (FileEvent{created, OpCreate}) {
+		t.Errorf("event = %+v, want create of %s", got, created)
+	}
+
+	if err := os.WriteFile(file, []byte("package a // changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(); got != (FileEvent{file, OpWrite}) {
+		t.Errorf("event = %+v, want write of %s", got, file)
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(); got != (FileEvent{file, OpRemove}) {
+		t.Errorf("event = %+v, want removal of %s", got, file)
+	}
+}
+
+func TestPollingBackendCloseTwice(t *testing.T) {
+	b := newPollingBackend(time.Hour, func(string) bool { return false })
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,172 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeadChange describes HEAD moving from one commit to another, e.g. after a
+// commit, checkout, pull or rebase.
+type HeadChange struct {
+	Old   string
+	New   string
+	Files []string // Paths relative to the watched directory
+}
+
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// IsRepo reports whether dir is inside a git work tree.
+func IsRepo(dir string) bool {
+	out, err := run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// Head returns the commit hash HEAD currently points to.
+func Head(dir string) (string, error) {
+	return run(dir, "rev-parse", "HEAD")
+}
+
+// Branch returns the name of the checked out branch, or "HEAD" when HEAD is
+// detached.
+func Branch(dir string) (string, error) {
+	return run(dir, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// ChangedFiles lists files that differ between two commits. An empty "to"
+// compares against the working tree, so uncommitted changes are included.
+// Paths are relative to dir and files outside of it are omitted.
+func ChangedFiles(dir, from, to string) ([]string, error) {
+	args := []string{"diff", "--name-only", "--relative", from}
+	if to != "" {
+		args = append(args, to)
+	}
+	out, err := run(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// ChangedSince lists files changed on the current branch relative to ref,
+// measured from their merge base, including uncommitted changes and files
+// not yet added that are not ignored.
+func ChangedSince(dir, ref string) ([]string, error) {
+	base, err := run(dir, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	files, err := ChangedFiles(dir, base, "")
+	if err != nil {
+		return nil, err
+	}
+	out, err := run(dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	if out != "" {
+		files = append(files, strings.Split(out, "\n")...)
+	}
+	return files, nil
+}
+
+// HeadWatcher polls HEAD and reports when it moves.
+type HeadWatcher struct {
+	Changes  chan HeadChange
+	dir      string
+	interval time.Duration
+	head     string
+	mu       sync.Mutex
+	done     chan bool
+}
+
+func NewHeadWatcher(dir string, interval time.Duration) (*HeadWatcher, error) {
+	head, err := Head(dir)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	return &HeadWatcher{
+		Changes:  make(chan HeadChange),
+		dir:      dir,
+		interval: interval,
+		head:     head,
+		done:     make(chan bool),
+	}, nil
+}
+
+// Check compares HEAD with the last seen commit and returns the change, if
+// any. Each move is reported exactly once, whether through Check or Changes.
+func (w *HeadWatcher) Check() (*HeadChange, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	head, err := Head(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	if head == w.head {
+		return nil, nil
+	}
+
+	files, err := ChangedFiles(w.dir, w.head, head)
+	if err != nil {
+		return nil, err
+	}
+	change := &HeadChange{Old: w.head, New: head, Files: files}
+	w.head = head
+	return change, nil
+}
+
+func (w *HeadWatcher) Start() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			change, err := w.Check()
+			if err != nil {
+				// Transient during rebases and other multi-step operations
+				continue
+			}
+			if change != nil {
+				select {
+				case w.Changes <- *change:
+				case <-w.done:
+					return
+				}
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *HeadWatcher) Stop() {
+	w.done <- true
+}
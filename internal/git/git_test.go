@@ -0,0 +1,103 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// repo creates a git repository with a committed main.go and lib/lib.go.
+func repo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	write(t, dir, "main.go", "package main\n")
+	write(t, dir, "lib/lib.go", "package lib\n")
+	write(t, dir, ".gitignore", "*.log\n")
+	commit(t, dir, "initial")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	out, err := run(dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func write(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commit(t *testing.T, dir, msg string) string {
+	t.Helper()
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", msg)
+	return git(t, dir, "rev-parse", "HEAD")
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := repo(t)
+	first := git(t, dir, "rev-parse", "HEAD")
+	write(t, dir, "lib/lib.go", "package lib\n\nfunc F() {}\n")
+	second := commit(t, dir, "change lib")
+	write(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+
+	tests := []struct {
+		name     string
+		dir      string
+		from, to string
+		want     []string
+	}{
+		{"between commits", dir, first, second, []string{"lib/lib.go"}},
+		{"against the working tree", dir, first, "", []string{"lib/lib.go", "main.go"}},
+		{"nothing changed", dir, second, second, nil},
+		{"relative to a subdirectory", filepath.Join(dir, "lib"), first, "", []string{"lib.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ChangedFiles(tt.dir, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ChangedFiles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	dir := repo(t)
+	base := git(t, dir, "rev-parse", "HEAD")
+	git(t, dir, "checkout", "-q", "-b", "feature")
+	write(t, dir, "lib/lib.go", "package lib\n\nfunc F() {}\n")
+	commit(t, dir, "change lib")
+	write(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	write(t, dir, "lib/new.go", "package lib\n")
+	write(t, dir, "debug.log", "ignored\n")
+
+	got, err := ChangedSince(dir, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	if want := []string{"lib/lib.go", "lib/new.go", "main.go"}; !slices.Equal(got, want) {
+		t.Errorf("ChangedSince = %q, want %q", got, want)
+	}
+
+	if _, err := ChangedSince(dir, "no-such-ref"); err == nil {
+		t.Error("ChangedSince of an unknown ref succeeded")
+	}
+}
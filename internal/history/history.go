@@ -0,0 +1,252 @@
+// Package history keeps finished test runs on disk, one JSON file per run,
+// so that later runs can be compared with earlier ones.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// idFormat names runs after their UTC start time, so that IDs sort in run
+// order.
+const idFormat = "20060102T150405.000Z"
+
+// ErrNotFound is returned by Load for an unknown run ID.
+var ErrNotFound = errors.New("run not found")
+
+// Store is a directory of saved runs. It is safe for concurrent use.
+type Store struct {
+	dir     string
+	maxRuns int
+
+	mu sync.Mutex
+}
+
+// New returns a store in dir keeping at most maxRuns runs; 0 keeps all of
+// them. The directory is created on the first Save.
+func New(dir string, maxRuns int) *Store {
+	return &Store{dir: dir, maxRuns: maxRuns}
+}
+
+// Dir is the directory the runs are stored in.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// ID is the ID a result is saved under.
+func ID(result *engine.TestResult) string {
+	return result.Timestamp.UTC().Format(idFormat)
+}
+
+// Save writes result and deletes the oldest runs beyond the limit. It
+// returns the run's ID.
+func (s *Store) Save(result *engine.TestResult) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+
+	// Write then rename so readers never see a partial run
+	id := ID(result)
+	tmp, err := os.CreateTemp(s.dir, ".run-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), s.path(id)); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return id, s.prune()
+}
+
+// prune must be called with mu held.
+func (s *Store) prune() error {
+	if s.maxRuns <= 0 {
+		return nil
+	}
+	ids, err := s.list()
+	if err != nil {
+		return err
+	}
+	for len(ids) > s.maxRuns {
+		if err := os.Remove(s.path(ids[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// List returns the IDs of the saved runs, oldest first.
+func (s *Store) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list()
+}
+
+func (s *Store) list() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if id, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Load reads the run with the given ID.
+func (s *Store) Load(id string) (*engine.TestResult, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	data, err := os.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result engine.TestResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("run %s: %w", id, err)
+	}
+	return &result, nil
+}
+
+// Resolve turns a run reference into an ID: an ID, "latest" for the newest
+// run or "latest~N" for the run N before it.
+func (s *Store) Resolve(ref string) (string, error) {
+	rest, ok := strings.CutPrefix(ref, "latest")
+	if !ok {
+		return ref, nil
+	}
+	back := 0
+	if rest != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(rest, "~"))
+		if rest[0] != '~' || err != nil || n < 0 {
+			return "", fmt.Errorf("%w: %q", ErrNotFound, ref)
+		}
+		back = n
+	}
+	ids, err := s.List()
+	if err != nil {
+		return "", err
+	}
+	if back >= len(ids) {
+		return "", fmt.Errorf("%w: %q (%d runs saved)", ErrNotFound, ref, len(ids))
+	}
+	return ids[len(ids)-1-back], nil
+}
+
+// Recent loads the last n runs, oldest first. Runs that cannot be read are
+// skipped.
+func (s *Store) Recent(n int) ([]*engine.TestResult, error) {
+	ids, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > n {
+		ids = ids[len(ids)-n:]
+	}
+	runs := make([]*engine.TestResult, 0, len(ids))
+	for _, id := range ids {
+		if run, err := s.Load(id); err == nil {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// Until loads the runs up to and including id, oldest first, e.g. for the
+// last result of every package as of that run. Earlier runs that cannot be
+// read are skipped.
+func (s *Store) Until(id string) ([]*engine.TestResult, error) {
+	ids, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	i := sort.SearchStrings(ids, id)
+	if i == len(ids) || ids[i] != id {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	runs := make([]*engine.TestResult, 0, i+1)
+	for _, earlier := range ids[:i] {
+		if run, err := s.Load(earlier); err == nil {
+			runs = append(runs, run)
+		}
+	}
+	run, err := s.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	return append(runs, run), nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// ReadFile reads a run from a file outside of a store: a saved run, or the
+// output of --format json, of which the last result record is used.
+func ReadFile(path string) (*engine.TestResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var result engine.TestResult
+	if err := json.Unmarshal(data, &result); err == nil && result.Packages != nil {
+		return &result, nil
+	}
+
+	var last *engine.TestResult
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var record struct {
+			Type   string             `json:"type"`
+			Result *engine.TestResult `json:"result"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &record) == nil && record.Type == "result" && record.Result != nil {
+			last = record.Result
+		}
+	}
+	if last == nil {
+		return nil, fmt.Errorf("%s: no test run found", path)
+	}
+	return last, nil
+}
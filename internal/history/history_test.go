@@ -0,0 +1,138 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// save stores n runs a minute apart and returns their IDs, oldest first.
+func save(t *testing.T, s *Store, n int) []string {
+	t.Helper()
+	var ids []string
+	for i := 0; i < n; i++ {
+		result := &engine.TestResult{
+			Timestamp:  time.Date(2026, 10, 19, 12, i, 0, 0, time.UTC),
+			Packages:   map[string]*engine.PackageResult{},
+			TotalTests: i,
+		}
+		id, err := s.Save(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestResolve(t *testing.T) {
+	s := New(t.TempDir(), 0)
+	ids := save(t, s, 3)
+
+	tests := []struct {
+		ref  string
+		want string // "" for ErrNotFound
+	}{
+		{"latest", ids[2]},
+		{"latest~0", ids[2]},
+		{"latest~1", ids[1]},
+		{"latest~2", ids[0]},
+		{"latest~3", ""},
+		{"latest~-1", ""},
+		{"latest~", ""},
+		{"latest1", ""},
+		{ids[1], ids[1]},
+	}
+	for _, tt := range tests {
+		got, err := s.Resolve(tt.ref)
+		if tt.want == "" {
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("Resolve(%q) = %q, %v, want ErrNotFound", tt.ref, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+		}
+	}
+
+	if _, err := New(t.TempDir(), 0).Resolve("latest"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(latest) of an empty store: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUntil(t *testing.T) {
+	s := New(t.TempDir(), 0)
+	ids := save(t, s, 4)
+
+	tests := []struct {
+		id   string
+		want []int // TotalTests of the runs returned
+	}{
+		{ids[0], []int{0}},
+		{ids[2], []int{0, 1, 2}},
+		{ids[3], []int{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		runs, err := s.Until(tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, run := range runs {
+			got = append(got, run.TotalTests)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Until(%s) = runs %v, want %v", tt.id, got, tt.want)
+		}
+	}
+
+	if _, err := s.Until("20260101T000000.000Z"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Until of an unknown run: err = %v, want ErrNotFound", err)
+	}
+
+	// An unreadable earlier run is skipped
+	if err := os.WriteFile(s.path(ids[1]), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := s.Until(ids[2])
+	if err != nil || len(runs) != 2 {
+		t.Errorf("Until past a corrupt run = %d runs, %v, want 2", len(runs), err)
+	}
+}
+
+func TestSavePrunes(t *testing.T) {
+	tests := []struct {
+		maxRuns int
+		saved   int
+		want    int // Index of the oldest run kept
+	}{
+		{0, 5, 0},
+		{3, 2, 0},
+		{3, 3, 0},
+		{3, 5, 2},
+		{1, 4, 3},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		s := New(dir, tt.maxRuns)
+		ids := save(t, s, tt.saved)
+		got, err := s.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := ids[tt.want:]; !slices.Equal(got, want) {
+			t.Errorf("max_runs %d after %d runs: kept %v, want %v", tt.maxRuns, tt.saved, got, want)
+		}
+		// Only run files remain, no temporary ones
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != len(got) {
+			t.Errorf("max_runs %d: %d files in %s, want %d", tt.maxRuns, len(entries), filepath.Base(dir), len(got))
+		}
+	}
+}
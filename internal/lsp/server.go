@@ -0,0 +1,627 @@
+// Package lsp is a Language Server Protocol bridge started with
+// "devtestrider lsp". It publishes failing tests, compile errors and vet issues as
+// diagnostics, puts "run test" and "debug test" code lenses above test
+// functions and runs tests through the engine when the editor asks.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Commands offered through code lenses and workspace/executeCommand. Their
+// arguments are the document URI and, for tests, the test name.
+const (
+	CommandRunTest    = "devtestrider.runTest"
+	CommandDebugTest  = "devtestrider.debugTest"
+	CommandRunPackage = "devtestrider.runPackage"
+)
+
+// Lines of test output shown in a failing test's diagnostic.
+const diagnosticExcerpt = 10
+
+// Server answers LSP requests read from one stream on another.
+type Server struct {
+	conn *conn
+	load func() (*config.Config, error)
+
+	// ready is set once initialize succeeded; cfg, root and runner are
+	// only valid from then on.
+	ready  bool
+	cfg    *config.Config
+	root   string
+	runner *engine.Runner
+	// runMu serializes "go test" runs; the runner is not safe for
+	// concurrent use.
+	runMu sync.Mutex
+
+	mu        sync.Mutex
+	docs      map[string]string    // text of open documents by URI
+	failures  map[string]finding   // failing tests by "pkg test"
+	issues    map[string][]finding // vet issues by package directory
+	builds    map[string][]finding // compile errors by package
+	published map[string]bool      // URIs last published with diagnostics
+	debuggers []*exec.Cmd          // dlv sessions started by debugTest
+}
+
+// finding is a failing test, compile error or vet issue placed in a source
+// file.
+type finding struct {
+	file    string
+	line    int
+	message string
+}
+
+// New creates a server. load resolves the config once the workspace root is
+// known, since the editor may start the server elsewhere.
+func New(load func() (*config.Config, error)) *Server {
+	return &Server{
+		load:      load,
+		docs:      make(map[string]string),
+		failures:  make(map[string]finding),
+		issues:    make(map[string][]finding),
+		builds:    make(map[string][]finding),
+		published: make(map[string]bool),
+	}
+}
+
+// Serve handles messages from r, writing to w, until the client sends "exit"
+// or closes r.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	defer s.stopDebuggers()
+	defer func() {
+		if s.runner != nil {
+			s.runner.Kill()
+		}
+	}()
+
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) error {
+	// Until initialize succeeds, e.g. while the config is broken, requests
+	// are rejected and notifications dropped, as the protocol asks
+	if !s.ready && msg.Method != "initialize" && msg.Method != "shutdown" {
+		if msg.ID != nil {
+			return s.conn.replyError(msg.ID, codeServerNotInitialized, "server not initialized")
+		}
+		return nil
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.conn.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		if err := s.initialize(params); err != nil {
+			return s.conn.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		return s.conn.reply(msg.ID, initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:       textDocumentSyncOptions{OpenClose: true, Change: 1, Save: true},
+				CodeLensProvider:       codeLensOptions{},
+				ExecuteCommandProvider: executeCommandOptions{Commands: []string{CommandRunTest, CommandDebugTest, CommandRunPackage}},
+			},
+			ServerInfo: serverInfo{Name: "devtestrider"},
+		})
+
+	case "initialized":
+		go s.run("Initial run", []string{"./..."}, "")
+
+	case "shutdown":
+		return s.conn.reply(msg.ID, nil)
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if json.Unmarshal(msg.Params, &params) == nil {
+			s.mu.Lock()
+			s.docs[params.TextDocument.URI] = params.TextDocument.Text
+			s.mu.Unlock()
+		}
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if json.Unmarshal(msg.Params, &params) == nil && len(params.ContentChanges) > 0 {
+			s.mu.Lock()
+			s.docs[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.mu.Unlock()
+		}
+
+	case "textDocument/didClose":
+		var params documentParams
+		if json.Unmarshal(msg.Params, &params) == nil {
+			s.mu.Lock()
+			delete(s.docs, params.TextDocument.URI)
+			s.mu.Unlock()
+		}
+
+	case "textDocument/didSave":
+		var params documentParams
+		if json.Unmarshal(msg.Params, &params) == nil {
+			if path, ok := uriPath(params.TextDocument.URI); ok {
+				if pkgs := engine.PackagesForFiles([]string{path}, s.cfg.Watch.Ignore); len(pkgs) > 0 {
+					go s.run("Saved", pkgs, "")
+				}
+			}
+		}
+
+	case "textDocument/codeLens":
+		var params documentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.conn.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		return s.conn.reply(msg.ID, s.codeLenses(params.TextDocument.URI))
+
+	case "workspace/executeCommand":
+		var params executeCommandParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.conn.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		if err := s.execute(params); err != nil {
+			return s.conn.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		return s.conn.reply(msg.ID, nil)
+
+	default:
+		// Requests need an answer; unknown notifications are ignored
+		if msg.ID != nil {
+			return s.conn.replyError(msg.ID, codeMethodNotFound, "method not supported: "+msg.Method)
+		}
+	}
+	return nil
+}
+
+// initialize moves to the workspace root and prepares the runner.
+func (s *Server) initialize(params initializeParams) error {
+	if params.RootURI != "" {
+		root, ok := uriPath(params.RootURI)
+		if !ok {
+			return fmt.Errorf("unsupported root URI %q", params.RootURI)
+		}
+		if err := os.Chdir(root); err != nil {
+			return err
+		}
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	s.root = root
+
+	cfg, err := s.load()
+	if err != nil {
+		return err
+	}
+	s.cfg = cfg
+
+	s.runner = engine.NewRunner()
+	s.runner.Profile = cfg.ActiveProfile()
+	// go test must not write to stdout, which carries the protocol
+	s.runner.Stderr = os.Stderr
+	s.runner.Modules, err = engine.DiscoverModules(".", cfg.Watch.Ignore)
+	if err != nil {
+		log.Printf("Module discovery failed: %v", err)
+	}
+	s.ready = true
+	return nil
+}
+
+func (s *Server) execute(params executeCommandParams) error {
+	if len(params.Arguments) == 0 {
+		return fmt.Errorf("%s needs a document URI", params.Command)
+	}
+	path, ok := uriPath(params.Arguments[0])
+	if !ok {
+		return fmt.Errorf("unsupported URI %q", params.Arguments[0])
+	}
+	pkg := packagePattern(s.root, filepath.Dir(path))
+
+	switch params.Command {
+	case CommandRunPackage:
+		go s.run("Run package", []string{pkg}, "")
+	case CommandRunTest, CommandDebugTest:
+		if len(params.Arguments) < 2 {
+			return fmt.Errorf("%s needs a test name", params.Command)
+		}
+		name := params.Arguments[1]
+		if params.Command == CommandDebugTest {
+			return s.debug(filepath.Dir(path), name)
+		}
+		go s.run("Run "+name, []string{pkg}, "^"+regexp.QuoteMeta(name)+"$")
+	default:
+		return fmt.Errorf("unknown command %q", params.Command)
+	}
+	return nil
+}
+
+// run tests and vets pkgs, restricted to tests matching filter if set, and
+// publishes the resulting diagnostics.
+func (s *Server) run(label string, pkgs []string, filter string) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	s.logMessage(fmt.Sprintf("%s: testing %s", label, strings.Join(pkgs, " ")))
+	profile := s.runner.Profile
+	if filter != "" {
+		s.runner.Profile.Run = filter
+	}
+	result, err := s.runner.RunPackages(pkgs...)
+	s.runner.Profile = profile
+	if err != nil {
+		s.showMessage(messageError, fmt.Sprintf("Running tests failed: %v", err))
+		return
+	}
+
+	// A filtered run says nothing about the package's other tests or vet
+	var issues []string
+	if filter == "" {
+		issues, err = s.runner.Vet(pkgs...)
+		if err != nil {
+			log.Printf("Vet failed: %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.applyResult(result, filter == "")
+	s.applyBuildErrors(result)
+	if filter == "" && err == nil {
+		s.applyIssues(result, issues)
+	}
+	s.mu.Unlock()
+	s.publish()
+
+	status := "passed"
+	if !result.Success {
+		status = "failed"
+	}
+	summary := fmt.Sprintf("%s: tests %s, %d passed, %d failed, %d skipped in %.1fs",
+		label, status, result.PassedTests, result.FailedTests, result.SkippedTests, result.Duration)
+	s.logMessage(summary)
+	if filter != "" {
+		// An explicit run deserves a visible answer
+		typ := messageInfo
+		if !result.Success {
+			typ = messageError
+		}
+		s.showMessage(typ, summary)
+	}
+}
+
+// applyResult updates the failing tests with result. For a complete run,
+// failures of the run's packages that did not show up again are cleared.
+func (s *Server) applyResult(result *engine.TestResult, complete bool) {
+	for name, pkg := range result.Packages {
+		if complete {
+			for key := range s.failures {
+				if strings.HasPrefix(key, name+" ") {
+					delete(s.failures, key)
+				}
+			}
+		}
+		dir := filepath.Join(s.root, result.PackageDir(name))
+		for _, test := range pkg.AllTests() {
+			delete(s.failures, name+" "+test.Name)
+		}
+		// A parent failing through its subtests is reported on them
+		for _, test := range pkg.Failures() {
+			if h := pkg.Hung; h != nil && h.Test == test.Name && h.File != "" {
+				file := h.File
+				if !filepath.IsAbs(file) {
+					file = filepath.Join(dir, file)
+				}
+				message := fmt.Sprintf("%s hung for %.1fs (timeout %gs), blocked in [%s]", test.Name, h.Elapsed, h.Timeout, h.State)
+				s.failures[name+" "+test.Name] = finding{file: file, line: h.Line, message: message}
+				continue
+			}
+			if f, ok := locate(dir, test); ok {
+				s.failures[name+" "+test.Name] = f
+			}
+		}
+	}
+}
+
+// applyBuildErrors replaces the compile errors of the run's packages.
+func (s *Server) applyBuildErrors(result *engine.TestResult) {
+	for name, pkg := range result.Packages {
+		delete(s.builds, name)
+		for _, err := range pkg.BuildErrors {
+			file := filepath.Join(s.root, result.ModuleDir(name), err.File)
+			s.builds[name] = append(s.builds[name], finding{file: file, line: err.Line, message: err.Message})
+		}
+	}
+}
+
+// applyIssues replaces the vet issues of the run's packages.
+func (s *Server) applyIssues(result *engine.TestResult, issues []string) {
+	for name := range result.Packages {
+		delete(s.issues, filepath.Join(s.root, result.PackageDir(name)))
+	}
+	for _, text := range issues {
+		issue, ok := engine.ParseIssue(text)
+		if !ok {
+			continue
+		}
+		file := s.resolve(issue.File)
+		dir := filepath.Dir(file)
+		s.issues[dir] = append(s.issues[dir], finding{file: file, line: issue.Line, message: issue.Message})
+	}
+}
+
+// resolve makes a path printed by go vet absolute. Vet runs in each module's
+// directory, so the path is relative to the root or to one of the modules.
+func (s *Server) resolve(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	for _, mod := range s.runner.Modules {
+		path := filepath.Join(s.root, mod.Dir, file)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(s.root, file)
+}
+
+// publish sends the diagnostics of every file, and empty ones for files
+// that no longer have any.
+func (s *Server) publish() {
+	s.mu.Lock()
+	byURI := make(map[string][]diagnostic)
+	for key, f := range s.failures {
+		uri := fileURI(f.file)
+		test := key[strings.Index(key, " ")+1:]
+		byURI[uri] = append(byURI[uri], diagnostic{
+			Range:    lineRange(f.line),
+			Severity: severityError,
+			Source:   "devtestrider",
+			Message:  test + " failed\n" + f.message,
+		})
+	}
+	// go vet repeats compile errors, which are reported once as such
+	built := make(map[string]bool)
+	for _, errors := range s.builds {
+		for _, err := range errors {
+			uri := fileURI(err.file)
+			built[fmt.Sprintf("%s:%d", err.file, err.line)] = true
+			byURI[uri] = append(byURI[uri], diagnostic{
+				Range:    lineRange(err.line),
+				Severity: severityError,
+				Source:   "go build",
+				Message:  err.message,
+			})
+		}
+	}
+	for _, issues := range s.issues {
+		for _, issue := range issues {
+			if built[fmt.Sprintf("%s:%d", issue.file, issue.line)] {
+				continue
+			}
+			uri := fileURI(issue.file)
+			byURI[uri] = append(byURI[uri], diagnostic{
+				Range:    lineRange(issue.line),
+				Severity: severityWarning,
+				Source:   "go vet",
+				Message:  issue.message,
+			})
+		}
+	}
+
+	uris := make([]string, 0, len(byURI)+len(s.published))
+	for uri := range s.published {
+		if _, ok := byURI[uri]; !ok {
+			uris = append(uris, uri)
+		}
+	}
+	for uri := range byURI {
+		uris = append(uris, uri)
+	}
+	s.published = make(map[string]bool, len(byURI))
+	for uri := range byURI {
+		s.published[uri] = true
+	}
+	s.mu.Unlock()
+
+	sort.Strings(uris)
+	for _, uri := range uris {
+		diags := byURI[uri]
+		if diags == nil {
+			diags = []diagnostic{}
+		}
+		sort.Slice(diags, func(i, j int) bool { return diags[i].Range.Start.Line < diags[j].Range.Start.Line })
+		s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diags})
+	}
+}
+
+// testFunc matches the declaration of a test, benchmark, fuzz target or example.
+var testFunc = regexp.MustCompile(`^func ((?:Test|Benchmark|Fuzz|Example)\w*)\(`)
+
+// codeLenses returns the lenses of a test file: one running the package
+// above the package clause and run/debug lenses above each test function.
+func (s *Server) codeLenses(uri string) []codeLens {
+	lenses := []codeLens{}
+	path, ok := uriPath(uri)
+	if !ok || !strings.HasSuffix(path, "_test.go") {
+		return lenses
+	}
+
+	s.mu.Lock()
+	text, open := s.docs[uri]
+	s.mu.Unlock()
+	if !open {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return lenses
+		}
+		text = string(data)
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "package ") {
+			lenses = append(lenses, codeLens{
+				Range:   lspRange{Start: position{Line: i}, End: position{Line: i}},
+				Command: command{Title: "run package tests", Command: CommandRunPackage, Arguments: []any{uri}},
+			})
+			continue
+		}
+		m := testFunc.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		r := lspRange{Start: position{Line: i}, End: position{Line: i}}
+		lenses = append(lenses,
+			codeLens{Range: r, Command: command{Title: "run test", Command: CommandRunTest, Arguments: []any{uri, m[1]}}},
+			codeLens{Range: r, Command: command{Title: "debug test", Command: CommandDebugTest, Arguments: []any{uri, m[1]}}},
+		)
+	}
+	return lenses
+}
+
+// debug starts a headless Delve session for one test and tells the user
+// where to attach.
+func (s *Server) debug(dir, name string) error {
+	dlv, err := exec.LookPath("dlv")
+	if err != nil {
+		s.showMessage(messageError, "Debugging tests needs Delve: go install github.com/go-delve/delve/cmd/dlv@latest")
+		return nil
+	}
+
+	cmd := exec.Command(dlv, "test", dir, "--headless", "--listen=127.0.0.1:0", "--api-version=2", "--accept-multiclient",
+		"--", "-test.run", "^"+regexp.QuoteMeta(name)+"$")
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.debuggers = append(s.debuggers, cmd)
+	s.mu.Unlock()
+
+	go func() {
+		// Delve announces "API server listening at: 127.0.0.1:PORT"
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if addr, ok := strings.CutPrefix(scanner.Text(), "API server listening at: "); ok {
+				s.showMessage(messageInfo, fmt.Sprintf("Delve is debugging %s; attach your debugger to %s", name, addr))
+				break
+			}
+		}
+		io.Copy(io.Discard, stdout)
+		if err := cmd.Wait(); err != nil {
+			log.Printf("Delve for %s exited: %v", name, err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) stopDebuggers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cmd := range s.debuggers {
+		if cmd.ProcessState == nil {
+			cmd.Process.Kill()
+		}
+	}
+}
+
+func (s *Server) logMessage(text string) {
+	s.conn.notify("window/logMessage", showMessageParams{Type: messageLog, Message: text})
+}
+
+func (s *Server) showMessage(typ int, text string) {
+	s.conn.notify("window/showMessage", showMessageParams{Type: typ, Message: text})
+}
+
+// locate places a failing test in dir: at the last location in its output,
+// or else at its declaration.
+func locate(dir string, test *engine.TestCase) (finding, bool) {
+	// A panic's message comes first; otherwise the failure is at the end
+	output := test.Output
+	if crash := engine.PanicExcerpt(output); crash != nil {
+		output = crash[:min(len(crash), diagnosticExcerpt)]
+	} else if len(output) > diagnosticExcerpt {
+		output = output[len(output)-diagnosticExcerpt:]
+	}
+	lines := make([]string, len(output))
+	for i, line := range output {
+		lines[i] = strings.TrimSpace(line)
+	}
+	message := strings.TrimSpace(strings.Join(lines, "\n"))
+
+	if file, line, ok := test.Location(); ok {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		return finding{file: file, line: line, message: message}, true
+	}
+
+	// Subtests are declared inside their top-level test
+	name, _, _ := strings.Cut(test.Name, "/")
+	files, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "func "+name+"(") {
+				return finding{file: file, line: i + 1, message: message}, true
+			}
+		}
+	}
+	return finding{}, false
+}
+
+// packagePattern returns the "go test" pattern of the package in dir.
+func packagePattern(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return "."
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+func uriPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
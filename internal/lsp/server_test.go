@@ -0,0 +1,63 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+)
+
+// frame encodes messages as a client would send them.
+func frame(messages ...string) *bytes.Buffer {
+	var b bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	return &b
+}
+
+func TestRequestsAfterFailedInitialize(t *testing.T) {
+	s := New(func() (*config.Config, error) {
+		return nil, errors.New("testrider.yml: broken")
+	})
+	in := frame(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didSave","params":{"textDocument":{"uri":"file:///tmp/x/a.go"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/codeLens","params":{"textDocument":{"uri":"file:///tmp/x/a_test.go"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	var out bytes.Buffer
+	if err := s.Serve(in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := newConn(&out, nil)
+	want := map[string]string{
+		"1": "testrider.yml: broken",
+		"2": "server not initialized",
+		"3": "",
+	}
+	for len(want) > 0 {
+		msg, err := conn.read()
+		if err != nil {
+			t.Fatalf("missing replies %v: %v", want, err)
+		}
+		id := string(msg.ID)
+		text, ok := want[id]
+		if !ok {
+			t.Fatalf("unexpected message %+v", msg)
+		}
+		delete(want, id)
+		switch {
+		case text == "" && msg.Error != nil:
+			t.Errorf("reply %s: unexpected error %q", id, msg.Error.Message)
+		case text != "" && (msg.Error == nil || !strings.Contains(msg.Error.Message, text)):
+			t.Errorf("reply %s: error %+v, want %q", id, msg.Error, text)
+		}
+	}
+}
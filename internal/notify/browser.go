@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Publisher pushes a named event to connected dashboards; implemented by
+// server.Server.
+type Publisher interface {
+	Publish(event string, v any)
+}
+
+// Browser raises a Notification API popup in every open dashboard by sending
+// a "notification" event over the SSE stream.
+type Browser struct {
+	publisher Publisher
+}
+
+func NewBrowser(p Publisher) *Browser {
+	return &Browser{publisher: p}
+}
+
+func (b *Browser) Name() string { return "browser" }
+
+// browserNotification is the payload of the "notification" event.
+type browserNotification struct {
+	Summary
+	Message string `json:"message"`
+}
+
+func (b *Browser) Notify(ctx context.Context, result *engine.TestResult) error {
+	s := summarize(result)
+	b.publisher.Publish("notification", browserNotification{Summary: s, Message: s.Message()})
+	return nil
+}
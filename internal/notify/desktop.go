@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gen2brain/beeep"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// How many failing tests a desktop notification lists.
+const desktopFailures = 3
+
+// Desktop shows a native OS notification listing the first failing tests.
+// Clicking it opens the dashboard where the OS backend supports actions.
+// Like every channel it is called from the Dispatcher's queue, so a slow
+// D-Bus or osascript call never blocks a run.
+type Desktop struct {
+	url string
+	platform
+}
+
+type desktopMessage struct {
+	title   string
+	body    string
+	success bool
+}
+
+// NewDesktop creates a Desktop notifier whose notifications open url when
+// clicked.
+func NewDesktop(url string) *Desktop {
+	return &Desktop{url: url}
+}
+
+func (d *Desktop) Name() string { return "desktop" }
+
+func (d *Desktop) Notify(ctx context.Context, result *engine.TestResult) error {
+	s := summarize(result)
+	return d.show(desktopMessage{title: s.Title, body: desktopBody(s), success: result.Success})
+}
+
+// Close releases the connection used to handle clicks, if any.
+func (d *Desktop) Close() error {
+	d.closePlatform()
+	return nil
+}
+
+// showFallback uses beeep, which works everywhere but cannot react to clicks.
+func (d *Desktop) showFallback(msg desktopMessage) error {
+	var icon any = ""
+	if data := encodePNG(statusIcon(msg.success)); data != nil {
+		icon = data
+	}
+	return beeep.Notify(msg.title, msg.body, icon)
+}
+
+// desktopBody lists the counts and the first failing tests with their
+// package name, e.g. "engine: TestRunner".
+func desktopBody(s Summary) string {
+	if s.Status != "failed" {
+		return fmt.Sprintf("%d/%d passed in %.1fs", s.Passed, s.Total, s.Duration)
+	}
+
+	lines := []string{fmt.Sprintf("%d failed, %d passed", s.Failed, s.Passed)}
+	for i, f := range s.Failures {
+		if i == desktopFailures {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(s.Failures)-desktopFailures))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("✗ %s: %s", path.Base(f.Package), f.Test))
+	}
+	return strings.Join(lines, "\n")
+}
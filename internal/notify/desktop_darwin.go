@@ -0,0 +1,37 @@
+//go:build darwin
+
+package notify
+
+import (
+	"os"
+	"os/exec"
+)
+
+type platform struct{}
+
+// show uses terminal-notifier, which can open the dashboard on click, when
+// it is installed and falls back to beeep otherwise.
+func (d *Desktop) show(msg desktopMessage) error {
+	notifier, err := exec.LookPath("terminal-notifier")
+	if err != nil || d.url == "" {
+		return d.showFallback(msg)
+	}
+
+	args := []string{"-title", "DevTestrider", "-subtitle", msg.title, "-message", msg.body, "-group", "DevTestrider", "-open", d.url}
+	if data := encodePNG(statusIcon(msg.success)); data != nil {
+		if f, err := os.CreateTemp("", "devtestrider-*.png"); err == nil {
+			defer os.Remove(f.Name())
+			_, err = f.Write(data)
+			f.Close()
+			if err == nil {
+				args = append(args, "-contentImage", f.Name())
+			}
+		}
+	}
+	if err := exec.Command(notifier, args...).Run(); err != nil {
+		return d.showFallback(msg)
+	}
+	return nil
+}
+
+func (d *Desktop) closePlatform() {}
@@ -0,0 +1,88 @@
+//go:build linux
+
+package notify
+
+import (
+	"os/exec"
+	"sync"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+// platform keeps the D-Bus connection open so clicks on notifications sent
+// earlier can still be handled.
+type platform struct {
+	notifier notify.Notifier
+	mu       sync.Mutex
+	sent     map[uint32]bool
+}
+
+// show sends the notification over D-Bus with a default action opening the
+// dashboard, and falls back to beeep without a session bus.
+func (d *Desktop) show(msg desktopMessage) error {
+	if d.url == "" {
+		return d.showFallback(msg)
+	}
+	if d.notifier == nil {
+		if err := d.connect(); err != nil {
+			return d.showFallback(msg)
+		}
+	}
+
+	n := notify.Notification{
+		AppName:       "DevTestrider",
+		Summary:       msg.title,
+		Body:          msg.body,
+		Actions:       []notify.Action{notify.NewDefaultAction("Open dashboard")},
+		ExpireTimeout: notify.ExpireTimeoutSetByNotificationServer,
+	}
+	if icon := statusIcon(msg.success); icon != nil {
+		n.AddHint(notify.HintImageDataRGBA(icon))
+	}
+	id, err := d.notifier.SendNotification(n)
+	if err != nil {
+		return d.showFallback(msg)
+	}
+	d.mu.Lock()
+	d.sent[id] = true
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *Desktop) connect() error {
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return err
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	d.sent = make(map[uint32]bool)
+	n, err := notify.New(conn, notify.WithOnAction(func(s *notify.ActionInvokedSignal) {
+		d.mu.Lock()
+		ours := d.sent[s.ID]
+		d.mu.Unlock()
+		if ours && s.ActionKey == "default" {
+			exec.Command("xdg-open", d.url).Start()
+		}
+	}))
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	d.notifier = n
+	return nil
+}
+
+func (d *Desktop) closePlatform() {
+	if d.notifier != nil {
+		d.notifier.Close()
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+package notify
+
+type platform struct{}
+
+// show has no click-through here; beeep handles the notification.
+func (d *Desktop) show(msg desktopMessage) error {
+	return d.showFallback(msg)
+}
+
+func (d *Desktop) closePlatform() {}
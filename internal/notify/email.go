@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Email sends a plain-text summary over SMTP.
+type Email struct {
+	cfg config.EmailConfig
+}
+
+func NewEmail(cfg config.EmailConfig) *Email {
+	return &Email{cfg: cfg}
+}
+
+func (e *Email) Name() string { return "email" }
+
+func (e *Email) Notify(ctx context.Context, result *engine.TestResult) error {
+	msg := e.message(summarize(result))
+	return withRetry(ctx, e.cfg.Timeout, e.cfg.Retries, func(ctx context.Context) error {
+		return e.send(ctx, msg)
+	})
+}
+
+func (e *Email) message(s Summary) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: [DevTestrider] %s\r\n", s.Message())
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "%s\r\n\r\n", s.Title)
+	fmt.Fprintf(&b, "Total:    %d\r\nPassed:   %d\r\nFailed:   %d\r\nSkipped:  %d\r\nDuration: %.2fs\r\n",
+		s.Total, s.Passed, s.Failed, s.Skipped, s.Duration)
+	if len(s.Failures) > 0 {
+		b.WriteString("\r\nFailing tests:\r\n")
+		for _, f := range s.Failures {
+			fmt.Fprintf(&b, "  %s (%s)\r\n", f.Test, f.Package)
+		}
+	}
+	return []byte(b.String())
+}
+
+func (e *Email) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(e.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
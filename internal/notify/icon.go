@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"sync"
+
+	"github.com/ismailtsdln/DevTestrider/assets"
+)
+
+const iconSize = 128
+
+var (
+	iconOnce           sync.Once
+	passIcon, failIcon *image.RGBA
+)
+
+// statusIcon returns the logo scaled to iconSize with a green badge for a
+// passing run or a red one for a failing run. It is nil if the logo cannot
+// be decoded.
+func statusIcon(success bool) *image.RGBA {
+	iconOnce.Do(func() {
+		logo, _, err := image.Decode(bytes.NewReader(assets.Logo))
+		if err != nil {
+			log.Printf("Failed to decode notification icon: %v", err)
+			return
+		}
+		small := scale(logo, iconSize)
+		passIcon = badge(small, color.RGBA{R: 16, G: 185, B: 129, A: 255})
+		failIcon = badge(small, color.RGBA{R: 244, G: 63, B: 94, A: 255})
+	})
+	if success {
+		return passIcon
+	}
+	return failIcon
+}
+
+// scale shrinks src to size×size by averaging the source pixels covered by
+// each destination pixel.
+func scale(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/size, b.Min.Y+(y+1)*b.Dy()/size
+		for x := 0; x < size; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/size, b.Min.X+(x+1)*b.Dx()/size
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a, n = r+cr, g+cg, bl+cb, a+ca, n+1
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: uint8(a / n >> 8)})
+		}
+	}
+	return dst
+}
+
+// badge returns a copy of img with a white-rimmed dot of color c in the
+// bottom right corner.
+func badge(img *image.RGBA, c color.RGBA) *image.RGBA {
+	out := image.NewRGBA(img.Bounds())
+	copy(out.Pix, img.Pix)
+
+	size := img.Bounds().Dx()
+	radius := size / 5
+	cx, cy := size-radius-2, size-radius-2
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			d := (x-cx)*(x-cx) + (y-cy)*(y-cy)
+			switch {
+			case d <= (radius-3)*(radius-3):
+				out.SetRGBA(x, y, c)
+			case d <= radius*radius:
+				out.SetRGBA(x, y, white)
+			}
+		}
+	}
+	return out
+}
+
+// encodePNG returns img as PNG data, or nil.
+func encodePNG(img *image.RGBA) []byte {
+	if img == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
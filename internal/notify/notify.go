@@ -1,39 +1,292 @@
 package notify
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
+	"sort"
+	"sync"
+	"time"
 
-	"github.com/gen2brain/beeep"
 	"github.com/ismailtsdln/DevTestrider/internal/config"
 	"github.com/ismailtsdln/DevTestrider/internal/engine"
 )
 
-func SendNotification(cfg config.NotificationsConfig, result *engine.TestResult) {
-	if !cfg.Enable {
+// Notifier delivers a run summary over one channel.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, result *engine.TestResult) error
+}
+
+// Dispatcher sends runs matching the notification rules to the notifiers of
+// the enabled channels. It remembers the last result of every package to
+// detect transitions, since watch-mode runs only test some packages, and the
+// last delivery per channel for rate limiting.
+type Dispatcher struct {
+	cfg       config.NotificationsConfig
+	channels  []*channel
+	publisher Publisher
+	url       string
+	packages  map[string]*engine.PackageResult
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+// NewDispatcher creates a Dispatcher. The browser channel publishes through
+// p and is skipped when p is nil; desktop notifications open dashboardURL
+// when clicked.
+func NewDispatcher(cfg config.NotificationsConfig, p Publisher, dashboardURL string) *Dispatcher {
+	d := &Dispatcher{
+		publisher: p,
+		url:       dashboardURL,
+		packages:  make(map[string]*engine.PackageResult),
+		lastSent:  make(map[string]time.Time),
+	}
+	d.Configure(cfg)
+	return d
+}
+
+// Configure replaces the channel configuration, e.g. after a config reload.
+// Notifications already queued on the old channels are still delivered.
+func (d *Dispatcher) Configure(cfg config.NotificationsConfig) {
+	d.cfg = cfg
+	for _, c := range d.channels {
+		c.close()
+	}
+	d.channels = nil
+	for _, name := range cfg.Channels {
+		var n Notifier
+		switch name {
+		case "desktop":
+			n = NewDesktop(d.url)
+		case "browser":
+			if d.publisher == nil {
+				continue
+			}
+			n = NewBrowser(d.publisher)
+		case "slack":
+			n = NewSlack(cfg.Slack)
+		case "webhook":
+			w, err := NewWebhook(cfg.Webhook)
+			if err != nil {
+				log.Printf("Webhook notifications disabled: %v", err)
+				continue
+			}
+			n = w
+		case "email":
+			n = NewEmail(cfg.Email)
+		default:
+			continue
+		}
+		d.channels = append(d.channels, d.newChannel(n))
+	}
+}
+
+func (d *Dispatcher) Dispatch(result *engine.TestResult) {
+	if !d.cfg.Enable {
+		return
+	}
+	prev, cur := d.record(result)
+	why := reason(d.cfg.Rules, prev, cur, result)
+	if why == "" {
+		return
+	}
+	if quiet(d.cfg.Rules.QuietHours, time.Now()) {
+		log.Printf("Notification suppressed during quiet hours (%s)", why)
 		return
 	}
+	for _, c := range d.channels {
+		c.send(delivery{result, rateLimit(d.cfg.Rules, c.Name())})
+	}
+}
 
-	title := "DevTestrider"
-	message := fmt.Sprintf("Tests Passed: %d/%d", result.PassedTests, result.TotalTests)
-	if !result.Success {
-		message = fmt.Sprintf("Tests Failed! %d failed, %d passed", result.FailedTests, result.PassedTests)
+// record merges result into the known package state and returns the suite
+// before and after; before is nil for the first run.
+func (d *Dispatcher) record(result *engine.TestResult) (prev, cur *engine.TestResult) {
+	if len(d.packages) > 0 {
+		prev = suite(d.packages)
+	}
+	for name, pkg := range result.Packages {
+		d.packages[name] = pkg
 	}
+	return prev, suite(d.packages)
+}
 
-	// Desktop Notification
-	if contains(cfg.Channels, "desktop") {
-		err := beeep.Notify(title, message, "")
-		if err != nil {
-			log.Printf("Failed to send notification: %v", err)
+// suite builds a result of the last known state of every package.
+func suite(packages map[string]*engine.PackageResult) *engine.TestResult {
+	result := &engine.TestResult{Packages: maps.Clone(packages), Success: true}
+	for _, pkg := range packages {
+		if pkg.Failed() {
+			result.Success = false
 		}
 	}
+	return result
+}
+
+// due reports whether channel may send again under its rate limit.
+func (d *Dispatcher) due(channel string, limit time.Duration) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last, ok := d.lastSent[channel]
+	return !ok || time.Since(last) >= limit
+}
+
+// sent starts channel's rate limit window. Only deliveries that succeeded
+// count, so a failing endpoint does not silence the channel.
+func (d *Dispatcher) sent(channel string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastSent[channel] = time.Now()
+}
+
+// Notifications waiting per channel before new ones are dropped.
+const channelQueue = 8
+
+// channel delivers to one Notifier from its own goroutine, so a slow or
+// unreachable endpoint never holds up the watch loop.
+// The rate limit is checked as each notification leaves the queue.
+type channel struct {
+	Notifier
+	d     *Dispatcher
+	queue chan delivery
+}
+
+// delivery is a queued notification with the rate limit it is sent under.
+type delivery struct {
+	result *engine.TestResult
+	limit  time.Duration
+}
+
+func (d *Dispatcher) newChannel(n Notifier) *channel {
+	c := &channel{Notifier: n, d: d, queue: make(chan delivery, channelQueue)}
+	go c.loop()
+	return c
 }
 
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
+// send queues a notification, dropping it if the channel is backed up.
+func (c *channel) send(msg delivery) {
+	select {
+	case c.queue <- msg:
+	default:
+		log.Printf("Dropped %s notification: too many pending", c.Name())
+	}
+}
+
+// close stops the channel once its queue is delivered.
+func (c *channel) close() {
+	close(c.queue)
+}
+
+func (c *channel) loop() {
+	for msg := range c.queue {
+		if !c.d.due(c.Name(), msg.limit) {
+			continue
+		}
+		if err := c.Notify(context.Background(), msg.result); err != nil {
+			log.Printf("Failed to send %s notification: %v", c.Name(), err)
+			continue
+		}
+		c.d.sent(c.Name())
+	}
+	if closer, ok := c.Notifier.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// permanent marks an error that retrying cannot fix, such as a rejected
+// request.
+type permanent struct{ error }
+
+func (p permanent) Unwrap() error { return p.error }
+
+// withRetry calls fn up to retries+1 times with exponential backoff, giving
+// every attempt its own timeout. Permanent errors end it early.
+func withRetry(ctx context.Context, timeout time.Duration, retries int, fn func(ctx context.Context) error) error {
+	backoff := 500 * time.Millisecond
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = fn(attemptCtx)
+		cancel()
+		var p permanent
+		if err == nil || errors.As(err, &p) {
+			return err
+		}
+	}
+	return err
+}
+
+// Failure identifies a failing test.
+type Failure struct {
+	Package string `json:"package"`
+	Test    string `json:"test"`
+}
+
+// Summary is the channel-independent content of a notification.
+type Summary struct {
+	Title     string    `json:"title"`
+	Status    string    `json:"status"` // passed or failed
+	Total     int       `json:"total"`
+	Passed    int       `json:"passed"`
+	Failed    int       `json:"failed"`
+	Skipped   int       `json:"skipped"`
+	Duration  float64   `json:"duration"`
+	Timestamp time.Time `json:"timestamp"`
+	Failures  []Failure `json:"failures"`
+}
+
+func summarize(result *engine.TestResult) Summary {
+	s := Summary{
+		Title:     "Tests Passed",
+		Status:    "passed",
+		Total:     result.TotalTests,
+		Passed:    result.PassedTests,
+		Failed:    result.FailedTests,
+		Skipped:   result.SkippedTests,
+		Duration:  result.Duration,
+		Timestamp: result.Timestamp,
+		Failures:  failures(result),
+	}
+	if !result.Success {
+		s.Title, s.Status = "Tests Failed", "failed"
+	}
+	return s
+}
+
+// Message is the one-line text used by plain-text channels.
+func (s Summary) Message() string {
+	if s.Status == "failed" {
+		return fmt.Sprintf("Tests Failed! %d failed, %d passed", s.Failed, s.Passed)
+	}
+	return fmt.Sprintf("Tests Passed: %d/%d", s.Passed, s.Total)
+}
+
+// failures lists failing tests sorted by package and name.
+func failures(result *engine.TestResult) []Failure {
+	var out []Failure
+	for _, pkg := range result.Packages {
+		for _, test := range pkg.Failures() {
+			out = append(out, Failure{Package: pkg.Name, Test: test.Name})
 		}
 	}
-	return false
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Package != out[j].Package {
+			return out[i].Package < out[j].Package
+		}
+		return out[i].Test < out[j].Test
+	})
+	return out
 }
@@ -0,0 +1,279 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+func failingRun() *engine.TestResult {
+	return &engine.TestResult{
+		Timestamp:   time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		TotalTests:  2,
+		PassedTests: 1,
+		FailedTests: 1,
+		Packages: map[string]*engine.PackageResult{
+			"example.com/m/a": {
+				Name:   "example.com/m/a",
+				Status: "FAIL",
+				Tests: []*engine.TestCase{
+					{Name: "TestOK", Status: "PASS"},
+					{Name: "TestBroken", Status: "FAIL"},
+				},
+			},
+		},
+	}
+}
+
+func TestSlack(t *testing.T) {
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewSlack(config.SlackConfig{WebhookURL: srv.URL + "/services/T0/B0/secret", Timeout: time.Second})
+	if err := s.Notify(context.Background(), failingRun()); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := payload["text"].(string); !strings.Contains(text, "1 failed") {
+		t.Errorf("text = %q, want the failure count", text)
+	}
+	blocks, _ := json.Marshal(payload["blocks"])
+	if !strings.Contains(string(blocks), "TestBroken") {
+		t.Errorf("blocks %s do not list the failing test", blocks)
+	}
+}
+
+func TestSlackErrorRedactsURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no_service", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	s := NewSlack(config.SlackConfig{WebhookURL: srv.URL + "/services/T0/B0/secret", Timeout: time.Second})
+	err := s.Notify(context.Background(), failingRun())
+	if err == nil {
+		t.Fatal("want an error for 404")
+	}
+	if strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), srv.URL) {
+		t.Errorf("error %q should name %s only", err, srv.URL)
+	}
+
+	// Unreachable endpoints fail in the client, whose errors carry the URL too
+	s = NewSlack(config.SlackConfig{WebhookURL: "http://127.0.0.1:1/services/T0/B0/secret", Timeout: time.Second})
+	if err := s.Notify(context.Background(), failingRun()); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("error %v should not contain the webhook path", err)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	for _, tc := range []struct {
+		status   int
+		attempts int32
+	}{
+		{http.StatusBadRequest, 1},
+		{http.StatusTooManyRequests, 3},
+		{http.StatusBadGateway, 3},
+	} {
+		t.Run(strconv.Itoa(tc.status), func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			w, err := NewWebhook(config.WebhookConfig{URL: srv.URL, Timeout: time.Second, Retries: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Notify(context.Background(), failingRun()); err == nil {
+				t.Error("want an error")
+			}
+			if n := attempts.Load(); n != tc.attempts {
+				t.Errorf("attempts = %d, want %d", n, tc.attempts)
+			}
+		})
+	}
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	var body, auth, method string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, auth, method = string(data), r.Header.Get("Authorization"), r.Method
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook(config.WebhookConfig{
+		URL:      srv.URL,
+		Method:   http.MethodPut,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Template: `{"status":{{json .Status}},"failed":{{.Failed}}}`,
+		Timeout:  time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(context.Background(), failingRun()); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"status":"failed","failed":1}`; body != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+	if auth != "Bearer token" || method != http.MethodPut {
+		t.Errorf("got %s with Authorization %q, want PUT with the configured header", method, auth)
+	}
+}
+
+// smtpStub accepts one message per connection without TLS or auth and
+// records what it received.
+type smtpStub struct {
+	ln net.Listener
+
+	mu   sync.Mutex
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 stub ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250 stub")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = strings.TrimSuffix(strings.TrimPrefix(cmd, "MAIL FROM:<"), ">")
+			s.mu.Unlock()
+			reply("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.to = append(s.to, strings.TrimSuffix(strings.TrimPrefix(cmd, "RCPT TO:<"), ">"))
+			s.mu.Unlock()
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmail(t *testing.T) {
+	stub := newSMTPStub(t)
+	addr := stub.ln.Addr().(*net.TCPAddr)
+
+	e := NewEmail(config.EmailConfig{
+		Host:    "127.0.0.1",
+		Port:    addr.Port,
+		From:    "ci@example.com",
+		To:      []string{"dev@example.com", "lead@example.com"},
+		Timeout: time.Second,
+	})
+	if err := e.Notify(context.Background(), failingRun()); err != nil {
+		t.Fatal(err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if stub.from != "ci@example.com" || strings.Join(stub.to, ",") != "dev@example.com,lead@example.com" {
+		t.Errorf("envelope from %q to %v", stub.from, stub.to)
+	}
+	for _, want := range []string{"Subject: [DevTestrider] Tests Failed!", "TestBroken (example.com/m/a)"} {
+		if !strings.Contains(stub.data, want) {
+			t.Errorf("message lacks %q:\n%s", want, stub.data)
+		}
+	}
+}
+
+func TestDispatchDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	d := NewDispatcher(config.NotificationsConfig{
+		Enable:   true,
+		Channels: []string{"webhook"},
+		Webhook:  config.WebhookConfig{URL: srv.URL, Timeout: 10 * time.Second},
+		Rules:    config.RulesConfig{When: []string{"always"}},
+	}, nil, "")
+
+	start := time.Now()
+	d.Dispatch(failingRun())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Dispatch took %s waiting for the endpoint", elapsed)
+	}
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Error("notification was never sent")
+	}
+}
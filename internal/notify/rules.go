@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// reason returns why run deserves a notification, or "" if none of the
+// configured conditions holds. prev and cur are the last known result of
+// every package before and after run, so a partial run is judged against
+// the whole suite; prev is nil before the first run.
+func reason(rules config.RulesConfig, prev, cur, run *engine.TestResult) string {
+	run = filterPackages(run, rules.Packages)
+	if len(rules.Packages) > 0 && len(run.Packages) == 0 {
+		return ""
+	}
+	cur = filterPackages(cur, rules.Packages)
+	if prev != nil {
+		prev = filterPackages(prev, rules.Packages)
+	}
+
+	for _, when := range rules.When {
+		switch when {
+		case "always":
+			return "run finished"
+		case "failure":
+			if !run.Success {
+				return "tests failing"
+			}
+		case "transition":
+			// The first run counts as a transition from passing
+			wasPassing := prev == nil || prev.Success
+			if wasPassing && !cur.Success {
+				return "tests started failing"
+			}
+			if !wasPassing && cur.Success {
+				return "tests fixed"
+			}
+		case "new_failures":
+			if n := newFailures(prev, cur); n > 0 {
+				return fmt.Sprintf("%d new failing tests", n)
+			}
+		case "coverage_drop":
+			if pkg, drop := coverageDrop(prev, cur); drop >= rules.CoverageDrop && drop > 0 {
+				return fmt.Sprintf("coverage of %s dropped %.1f%%", pkg, drop)
+			}
+		}
+	}
+	return ""
+}
+
+// filterPackages returns result restricted to packages matching patterns, or
+// result itself when there are no patterns.
+func filterPackages(result *engine.TestResult, patterns []string) *engine.TestResult {
+	if len(patterns) == 0 {
+		return result
+	}
+	filtered := &engine.TestResult{
+		Timestamp: result.Timestamp,
+		Packages:  make(map[string]*engine.PackageResult),
+		Success:   true,
+	}
+	for name, pkg := range result.Packages {
+		if !engine.MatchPackage(name, patterns) {
+			continue
+		}
+		filtered.Packages[name] = pkg
+		if pkg.Failed() {
+			filtered.Success = false
+		}
+		for _, test := range pkg.AllTests() {
+			if !test.Leaf() {
+				filtered.ParentTests++
+				continue
+			}
+			filtered.TotalTests++
+			switch test.Status {
+			case "PASS":
+				filtered.PassedTests++
+			case "FAIL":
+				filtered.FailedTests++
+			case "SKIP":
+				filtered.SkippedTests++
+			}
+		}
+	}
+	return filtered
+}
+
+// newFailures counts tests failing in cur that did not fail in prev. Every
+// failure of the first run is new.
+func newFailures(prev, cur *engine.TestResult) int {
+	old := make(map[Failure]bool)
+	if prev != nil {
+		for _, f := range failures(prev) {
+			old[f] = true
+		}
+	}
+	n := 0
+	for _, f := range failures(cur) {
+		if !old[f] {
+			n++
+		}
+	}
+	return n
+}
+
+// coverageDrop returns the package whose coverage dropped most since prev,
+// comparing only packages present in both runs.
+func coverageDrop(prev, cur *engine.TestResult) (string, float64) {
+	if prev == nil {
+		return "", 0
+	}
+	var worst string
+	var drop float64
+	for name, pkg := range cur.Packages {
+		before, ok := prev.Packages[name]
+		if !ok {
+			continue
+		}
+		if d := before.Coverage - pkg.Coverage; d > drop {
+			worst, drop = name, d
+		}
+	}
+	return worst, drop
+}
+
+// quiet reports whether t falls inside the configured quiet hours.
+func quiet(hours config.QuietHoursConfig, t time.Time) bool {
+	if hours.Start == "" || hours.End == "" {
+		return false
+	}
+	start, err := config.ParseClock(hours.Start)
+	if err != nil {
+		return false
+	}
+	end, err := config.ParseClock(hours.End)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return now >= start && now < end
+	}
+	// The window wraps around midnight, e.g. 22:00-07:00
+	return now >= start || now < end
+}
+
+// rateLimit returns the minimum interval between notifications on channel.
+func rateLimit(rules config.RulesConfig, channel string) time.Duration {
+	if limit, ok := rules.ChannelRateLimits[channel]; ok {
+		return limit
+	}
+	return rules.RateLimit
+}
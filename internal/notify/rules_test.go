@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// run builds a result of packages given as name → failing tests; a package
+// without failing tests passes.
+func run(packages map[string][]string) *engine.TestResult {
+	result := &engine.TestResult{Packages: make(map[string]*engine.PackageResult), Success: true}
+	for name, failing := range packages {
+		pkg := &engine.PackageResult{Name: name, Status: "PASS"}
+		for _, test := range failing {
+			pkg.Tests = append(pkg.Tests, &engine.TestCase{Name: test, Status: "FAIL"})
+			pkg.Status = "FAIL"
+			result.Success = false
+		}
+		result.Packages[name] = pkg
+	}
+	return result
+}
+
+func TestReasonPartialRuns(t *testing.T) {
+	rules := config.RulesConfig{When: []string{"transition", "new_failures"}}
+	d := NewDispatcher(config.NotificationsConfig{Rules: rules}, nil, "")
+
+	steps := []struct {
+		run  *engine.TestResult
+		want string
+	}{
+		{run(map[string][]string{"a": {"TestA"}, "b": nil}), "tests started failing"},
+		// Another package passing does not fix a
+		{run(map[string][]string{"b": nil}), ""},
+		// a still fails the same test
+		{run(map[string][]string{"a": {"TestA"}}), ""},
+		{run(map[string][]string{"b": nil}), ""},
+		{run(map[string][]string{"a": {"TestA", "TestA2"}}), "1 new failing tests"},
+		{run(map[string][]string{"a": nil}), "tests fixed"},
+	}
+	for i, step := range steps {
+		prev, cur := d.record(step.run)
+		if got := reason(rules, prev, cur, step.run); got != step.want {
+			t.Errorf("run %d: reason = %q, want %q", i, got, step.want)
+		}
+	}
+}
+
+func TestRateLimitCountsOnlyDeliveries(t *testing.T) {
+	var attempts atomic.Int32
+	delivered := make(chan struct{}, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered <- struct{}{}
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(config.NotificationsConfig{
+		Enable:   true,
+		Channels: []string{"webhook"},
+		Webhook:  config.WebhookConfig{URL: srv.URL, Timeout: time.Second},
+		Rules:    config.RulesConfig{When: []string{"always"}, RateLimit: time.Hour},
+	}, nil, "")
+
+	result := run(map[string][]string{"a": nil})
+	d.Dispatch(result) // rejected by the endpoint
+	d.Dispatch(result) // delivered: the failure did not start the window
+	d.Dispatch(result) // rate limited
+	select {
+	case <-delivered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification delivered after a failed one")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("endpoint called %d times, want 2", n)
+	}
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Maximum number of failing tests listed in a Slack message.
+const slackMaxFailures = 10
+
+// Slack posts a Block Kit summary to an incoming webhook.
+type Slack struct {
+	cfg config.SlackConfig
+}
+
+func NewSlack(cfg config.SlackConfig) *Slack {
+	return &Slack{cfg: cfg}
+}
+
+func (s *Slack) Name() string { return "slack" }
+
+func (s *Slack) Notify(ctx context.Context, result *engine.TestResult) error {
+	payload := slackPayload(summarize(result))
+	return withRetry(ctx, s.cfg.Timeout, s.cfg.Retries, func(ctx context.Context) error {
+		return postJSON(ctx, "POST", s.cfg.WebhookURL, nil, payload)
+	})
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+func slackPayload(s Summary) map[string]any {
+	icon := ":white_check_mark:"
+	if s.Status == "failed" {
+		icon = ":x:"
+	}
+
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: s.Title}},
+		{Type: "section", Fields: []slackText{
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Passed:* %d", s.Passed)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Failed:* %d", s.Failed)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Skipped:* %d", s.Skipped)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Duration:* %.2fs", s.Duration)},
+		}},
+	}
+
+	if len(s.Failures) > 0 {
+		var b strings.Builder
+		b.WriteString("*Failing tests*\n")
+		for i, f := range s.Failures {
+			if i == slackMaxFailures {
+				fmt.Fprintf(&b, "_…and %d more_\n", len(s.Failures)-slackMaxFailures)
+				break
+			}
+			fmt.Fprintf(&b, "• `%s` %s\n", f.Test, f.Package)
+		}
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: b.String()}})
+	}
+
+	return map[string]any{
+		"text":   fmt.Sprintf("%s %s", icon, s.Message()),
+		"blocks": blocks,
+	}
+}
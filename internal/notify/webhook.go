@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"text/template"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Webhook sends a run summary to a generic HTTP endpoint. Without a template
+// the body is the JSON encoding of Summary.
+type Webhook struct {
+	cfg  config.WebhookConfig
+	tmpl *template.Template
+}
+
+// webhookData is what a webhook template is executed with.
+type webhookData struct {
+	Summary
+	Result *engine.TestResult
+}
+
+func NewWebhook(cfg config.WebhookConfig) (*Webhook, error) {
+	w := &Webhook{cfg: cfg}
+	if cfg.Template != "" {
+		tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+			"json": func(v any) (string, error) {
+				data, err := json.Marshal(v)
+				return string(data), err
+			},
+		}).Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		w.tmpl = tmpl
+	}
+	return w, nil
+}
+
+func (w *Webhook) Name() string { return "webhook" }
+
+func (w *Webhook) Notify(ctx context.Context, result *engine.TestResult) error {
+	summary := summarize(result)
+
+	var body []byte
+	if w.tmpl != nil {
+		var buf bytes.Buffer
+		if err := w.tmpl.Execute(&buf, webhookData{Summary: summary, Result: result}); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+	} else {
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		body = data
+	}
+
+	method := w.cfg.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	return withRetry(ctx, w.cfg.Timeout, w.cfg.Retries, func(ctx context.Context) error {
+		return send(ctx, method, w.cfg.URL, w.cfg.Headers, body)
+	})
+}
+
+func postJSON(ctx context.Context, method, url string, headers map[string]string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return send(ctx, method, url, headers, body)
+}
+
+// send makes one request. Errors name the endpoint by scheme and host only:
+// the path of a Slack webhook is its secret. Rejections other than timeouts
+// and rate limits are permanent and not retried.
+func send(ctx context.Context, method, endpoint string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid URL %s", redact(endpoint))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DevTestrider")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redact(endpoint)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("%s %s: %s: %s", method, redact(endpoint), resp.Status, bytes.TrimSpace(msg))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return permanent{err}
+		}
+		return err
+	}
+	return nil
+}
+
+// redact shortens endpoint to its scheme and host.
+func redact(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "<invalid URL>"
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -3,118 +3,391 @@ package orchestrator
 import (
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/charmbracelet/lipgloss"
 	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/console"
 	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/git"
+	"github.com/ismailtsdln/DevTestrider/internal/history"
 	"github.com/ismailtsdln/DevTestrider/internal/notify"
 	"github.com/ismailtsdln/DevTestrider/internal/report"
 	"github.com/ismailtsdln/DevTestrider/internal/server"
 )
 
+// Files rewritten by a HEAD move keep producing watcher events for a moment
+// after the move has been handled; those are dropped for this long.
+const gitSettleWindow = 3 * time.Second
+
 type Orchestrator struct {
 	cfg     *config.Config
 	runner  *engine.Runner
 	watcher *engine.Watcher
 	server  *server.Server
+	git     *git.HeadWatcher
+	notify  *notify.Dispatcher
+	history *history.Store
+
+	gitFiles map[string]bool
+	gitMoved time.Time
+
+	loadConfig func() (*config.Config, error)
+
+	out      Output
+	commands chan func()
+	last     *engine.TestResult
+}
+
+// Output shows what the orchestrator is doing. The default is a
+// console.Printer in the plain format; SetOutput replaces it.
+type Output interface {
+	// RunStarted announces a test run and what triggered it.
+	RunStarted(label, detail string)
+	Info(label, detail string)
+	Warn(label, detail string)
+	Result(result *engine.TestResult)
+}
+
+// configEvent tells dashboard clients about a config reload.
+type configEvent struct {
+	Valid           bool     `json:"valid"`
+	Error           string   `json:"error,omitempty"`
+	RestartRequired []string `json:"restart_required,omitempty"`
 }
 
 func New(cfg *config.Config, r *engine.Runner, w *engine.Watcher, s *server.Server) *Orchestrator {
+	out, _ := console.New(os.Stdout, console.FormatPlain)
 	return &Orchestrator{
 		cfg:     cfg,
 		runner:  r,
 		watcher: w,
 		server:  s,
+		notify:  notify.NewDispatcher(cfg.Notifications, s, s.URL()),
+
+		out:      out,
+		commands: make(chan func(), 1),
+	}
+}
+
+// SetOutput replaces the terminal output, e.g. with another format or the
+// TUI.
+func (o *Orchestrator) SetOutput(out Output) {
+	o.out = out
+}
+
+// RerunAll runs every test of the watched modules. Like RerunFailed and
+// UseProfile it may be called from any goroutine; the work is done by the
+// Start loop.
+func (o *Orchestrator) RerunAll() {
+	o.commands <- func() {
+		o.out.RunStarted("Rerun:", "all packages")
+		o.runPackages("./...")
+	}
+}
+
+// RerunFailed runs only the tests that failed in the previous run.
+func (o *Orchestrator) RerunFailed() {
+	o.commands <- func() {
+		pkgs, pattern := failedTests(o.last)
+		if len(pkgs) == 0 {
+			o.out.Info("Nothing to rerun:", "no failing tests")
+			return
+		}
+		o.out.RunStarted("Rerun:", fmt.Sprintf("failed tests in %d packages", len(pkgs)))
+
+		profile := o.runner.Profile
+		o.runner.Profile.Run = pattern
+		defer func() { o.runner.Profile = profile }()
+		o.runPackages(pkgs...)
+	}
+}
+
+// UseProfile switches the test profile for the following runs.
+func (o *Orchestrator) UseProfile(name string) {
+	o.commands <- func() {
+		profile, ok := o.cfg.Profiles[name]
+		if !ok {
+			o.out.Warn("Unknown profile:", name)
+			return
+		}
+		o.cfg.Profile = name
+		o.runner.Profile = profile
+		o.out.Info("Profile:", name)
+	}
+}
+
+func (o *Orchestrator) runPackages(pkgs ...string) {
+	result, err := o.runner.RunPackages(pkgs...)
+	if err != nil {
+		log.Printf("Error running tests: %v", err)
+		return
+	}
+	o.process(result, pkgs...)
+}
+
+// failedTests returns the packages with failing tests in result and a -run
+// pattern matching those tests.
+func failedTests(result *engine.TestResult) ([]string, string) {
+	if result == nil {
+		return nil, ""
+	}
+	var pkgs, names []string
+	seen := make(map[string]bool)
+	for _, name := range sortedPackages(result) {
+		failed := false
+		for _, test := range result.Packages[name].Failures() {
+			failed = true
+			// Subtests are rerun through their top-level test
+			top, _, _ := strings.Cut(test.Name, "/")
+			if !seen[top] {
+				seen[top] = true
+				names = append(names, regexp.QuoteMeta(top))
+			}
+		}
+		if failed {
+			pkgs = append(pkgs, name)
+		}
+	}
+	return pkgs, "^(" + strings.Join(names, "|") + ")$"
+}
+
+func sortedPackages(result *engine.TestResult) []string {
+	names := make([]string, 0, len(result.Packages))
+	for name := range result.Packages {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
+
+// EnableGit makes the orchestrator react to HEAD moves reported by hw.
+func (o *Orchestrator) EnableGit(hw *git.HeadWatcher) {
+	o.git = hw
+}
+
+// EnableHistory saves every finished run to store and adds analytics
+// computed from the saved runs to the reports.
+func (o *Orchestrator) EnableHistory(store *history.Store) {
+	o.history = store
+	o.server.SetHistory(store, o.cfg.Analytics)
+}
+
+// EnableConfigReload re-reads the config with load whenever the file at path
+// changes and applies whatever can change without a restart.
+func (o *Orchestrator) EnableConfigReload(path string, load func() (*config.Config, error)) error {
+	o.loadConfig = load
+	return o.watcher.WatchFile(path)
 }
 
 func (o *Orchestrator) Start(done chan bool) {
-	// Styles
-	infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
-	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
-	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("160"))
+	var gitChanges chan git.HeadChange
+	if o.git != nil {
+		gitChanges = o.git.Changes
+	}
 
 	for {
 		select {
 		case eventPath := <-o.watcher.Events:
-			fmt.Printf("\n%s %s\n", infoStyle.Render("File changed:"), eventPath)
+			// A checkout or pull touches many files; handle it as one HEAD move
+			if o.git != nil {
+				change, err := o.git.Check()
+				if err != nil {
+					log.Printf("Error checking git HEAD: %v", err)
+				} else if change != nil {
+					o.handleHeadChange(*change)
+					continue
+				}
+				if o.fromHeadChange(eventPath) {
+					continue
+				}
+			}
+
+			o.out.RunStarted("File changed:", eventPath)
 
 			// Run Tests
-			result, err := o.runner.RunTests(filepath.Dir(eventPath))
+			result, err := o.runner.RunTests(eventPath)
 			if err != nil {
 				log.Printf("Error running tests: %v", err)
 				continue
 			}
+			o.process(result, engine.PackagesForFiles([]string{eventPath}, o.cfg.Watch.Ignore)...)
 
-			// Run Analysis (go vet)
-			issues, err := engine.RunVet(filepath.Dir(eventPath))
-			if err == nil {
-				result.Issues = issues
-			} else {
-				log.Printf("Error running vet: %v", err)
-			}
+		case change := <-gitChanges:
+			o.handleHeadChange(change)
+
+		case command := <-o.commands:
+			command()
 
-			// Render Status Header
-			status := failStyle.Render("FAILED ❌")
-			if result.Success {
-				status = passStyle.Render("PASSED ✅")
+		case path := <-o.watcher.ConfigEvents:
+			if o.loadConfig != nil {
+				o.reloadConfig(path)
 			}
-			fmt.Printf("Status: %s (Duration: %.2fs)\n", status, result.Duration)
 
-			// Render Package Details
-			for _, pkg := range result.Packages {
-				pkgStatus := failStyle.Render("FAIL")
-				if pkg.Status == "PASS" {
-					pkgStatus = passStyle.Render("PASS")
-				}
+		case <-done:
+			return
+		}
+	}
+}
 
-				covStr := "N/A"
-				if pkg.Coverage > 0 {
-					covColor := "160" // Red
-					if pkg.Coverage > 50 {
-						covColor = "220"
-					} // Yellow
-					if pkg.Coverage > 80 {
-						covColor = "42"
-					} // Green
-					covStr = lipgloss.NewStyle().Foreground(lipgloss.Color(covColor)).Render(fmt.Sprintf("%.1f%%", pkg.Coverage))
-				}
+func (o *Orchestrator) handleHeadChange(change git.HeadChange) {
+	o.gitMoved = time.Now()
+	o.gitFiles = make(map[string]bool, len(change.Files))
+	for _, file := range change.Files {
+		if abs, err := filepath.Abs(file); err == nil {
+			o.gitFiles[abs] = true
+		}
+	}
 
-				fmt.Printf("  • %-40s %s  %s (%.2fs)\n",
-					pkg.Name,
-					pkgStatus,
-					covStr,
-					pkg.Duration,
-				)
-			}
+	o.out.RunStarted("HEAD moved:", fmt.Sprintf("%s..%s (%d files changed)", short(change.Old), short(change.New), len(change.Files)))
 
-			// Generate Reports
-			if len(o.cfg.Report.Formats) > 0 {
-				for _, fmtType := range o.cfg.Report.Formats {
-					var path string
-					var err error
-					switch fmtType {
-					case "html":
-						path, err = report.GenerateHTML(result, o.cfg.Report.OutputDir)
-					case "pdf":
-						path, err = report.GeneratePDF(result, o.cfg.Report.OutputDir)
-					}
-
-					if err != nil {
-						log.Printf("Failed to generate %s report: %v", fmtType, err)
-					} else if path != "" {
-						fmt.Printf("Report generated: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Render(path))
-					}
-				}
-			}
+	pkgs := engine.PackagesForFiles(change.Files, o.cfg.Watch.Ignore)
+	if len(pkgs) == 0 {
+		o.out.Info("No Go packages affected", "")
+		return
+	}
 
-			// Notifications & Broadcast
-			notify.SendNotification(o.cfg.Notifications, result)
-			o.server.Broadcast(result)
+	result, err := o.runner.RunPackages(pkgs...)
+	if err != nil {
+		log.Printf("Error running tests: %v", err)
+		return
+	}
+	o.process(result, pkgs...)
+}
 
-		case <-done:
-			return
+func (o *Orchestrator) reloadConfig(path string) {
+	cfg, err := o.loadConfig()
+	if err != nil {
+		o.out.Warn("Config reload failed, keeping previous config:", fmt.Sprintf("%s\n%v", path, err))
+		o.server.Publish("config", configEvent{Error: err.Error()})
+		return
+	}
+
+	if err := o.watcher.Reload(cfg.Watch); err != nil {
+		log.Printf("Error applying watch paths: %v", err)
+	}
+	o.runner.Profile = cfg.ActiveProfile()
+	o.notify.Configure(cfg.Notifications)
+	if o.history != nil {
+		o.server.SetHistory(o.history, cfg.Analytics)
+	}
+
+	restart := restartRequired(o.cfg, cfg)
+	o.cfg = cfg
+
+	o.out.Info("Config reloaded:", path)
+	if len(restart) > 0 {
+		o.out.Warn("Restart DevTestrider to apply:", strings.Join(restart, ", "))
+	}
+	o.server.Publish("config", configEvent{Valid: true, RestartRequired: restart})
+}
+
+// restartRequired lists changed settings that only take effect on startup.
+func restartRequired(old, cfg *config.Config) []string {
+	var fields []string
+	if old.Server.Port != cfg.Server.Port {
+		fields = append(fields, "server.port")
+	}
+	if old.Watch.Backend != cfg.Watch.Backend {
+		fields = append(fields, "watch.backend")
+	}
+	if old.Watch.PollInterval != cfg.Watch.PollInterval {
+		fields = append(fields, "watch.poll_interval")
+	}
+	if old.Git.Enable != cfg.Git.Enable {
+		fields = append(fields, "git.enable")
+	}
+	if old.Git.Interval != cfg.Git.Interval {
+		fields = append(fields, "git.interval")
+	}
+	if old.History != cfg.History {
+		fields = append(fields, "history")
+	}
+	return fields
+}
+
+// fromHeadChange reports whether a watcher event is a leftover from the
+// HEAD move that was just handled.
+func (o *Orchestrator) fromHeadChange(path string) bool {
+	if time.Since(o.gitMoved) > gitSettleWindow {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	return err == nil && o.gitFiles[abs]
+}
+
+func (o *Orchestrator) process(result *engine.TestResult, vetPaths ...string) {
+	// Run Analysis (go vet)
+	if len(vetPaths) > 0 {
+		issues, err := o.runner.Vet(vetPaths...)
+		if err == nil {
+			result.Issues = issues
+		} else {
+			log.Printf("Error running vet: %v", err)
 		}
 	}
+
+	o.last = result
+	o.out.Result(result)
+	insights := o.saveHistory(result)
+
+	if len(o.cfg.Report.Formats) > 0 {
+		o.writeReports(result, insights)
+	}
+
+	// Notifications & Broadcast
+	o.notify.Dispatch(result)
+	o.server.Broadcast(result)
+}
+
+// writeReports writes the run's reports in the configured formats.
+func (o *Orchestrator) writeReports(result *engine.TestResult, insights report.Insights) {
+	paths, err := report.Write(o.cfg, result, insights)
+	for _, path := range paths {
+		o.out.Info("Report generated:", path)
+	}
+	if err != nil {
+		log.Printf("Failed to generate reports: %v", err)
+	}
+}
+
+// saveHistory adds result to the run history and analyses it against the
+// earlier runs for the reports. Without a history there are no insights.
+func (o *Orchestrator) saveHistory(result *engine.TestResult) report.Insights {
+	if o.history == nil {
+		return report.Insights{}
+	}
+	if _, err := o.history.Save(result); err != nil {
+		log.Printf("Error saving run history: %v", err)
+		return report.Insights{}
+	}
+	runs, err := o.history.Recent(report.HistoryRuns(o.cfg))
+	if err != nil {
+		log.Printf("Error reading run history: %v", err)
+		return report.Insights{}
+	}
+
+	insights := report.NewInsights(runs, o.cfg)
+	if slow := insights.Slow; slow != nil && len(slow.Regressions) > 0 {
+		var names []string
+		for _, t := range slow.Regressions[:min(len(slow.Regressions), 3)] {
+			names = append(names, fmt.Sprintf("%s.%s (%.1fx)", t.Package[strings.LastIndex(t.Package, "/")+1:], t.Test, t.Ratio))
+		}
+		if more := len(slow.Regressions) - len(names); more > 0 {
+			names = append(names, fmt.Sprintf("%d more", more))
+		}
+		o.out.Warn("Slower than usual:", strings.Join(names, ", "))
+	}
+	return insights
+}
+
+func short(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
 }
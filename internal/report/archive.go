@@ -0,0 +1,377 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+)
+
+// reportExts are the extensions of the reports an Archive writes.
+var reportExts = []string{".html", ".pdf", ".md", ".json"}
+
+// Names an Archive keeps for itself.
+const (
+	latestName = "latest"
+	indexFile  = "index.html"
+	// manifestFile lists, one per line, the files the Archive wrote. Only
+	// those are ever replaced or deleted, so the output directory may be
+	// shared with other files, e.g. "docs" or the project root.
+	manifestFile = ".devtestrider-reports"
+)
+
+// unsafeName matches what report names may not contain: path separators
+// and anything else awkward in a file name.
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// NameData is what the report.name template is executed with.
+type NameData struct {
+	// Time is the run's start in local time as 20060102-150405.
+	Time      string
+	Timestamp time.Time
+	// Profile is the active test profile, if any.
+	Profile string
+	// Branch and Commit, abbreviated, are empty outside of a git
+	// repository. Branch is "HEAD" when it is detached.
+	Branch string
+	Commit string
+}
+
+// Archive is the output directory of the reports. It names new reports,
+// points the latest aliases at them, deletes reports beyond the retention
+// limits and keeps an index page of the rest. Files it did not write are
+// left alone.
+type Archive struct {
+	dir       string
+	name      *texttemplate.Template
+	retention config.RetentionConfig
+}
+
+func NewArchive(cfg config.ReportConfig) (*Archive, error) {
+	dir := cfg.OutputDir
+	if dir == "" {
+		dir = "."
+	}
+	name, err := texttemplate.New("name").Parse(cfg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report name: %w", err)
+	}
+	return &Archive{dir: dir, name: name, retention: cfg.Retention}, nil
+}
+
+// Base returns the path of a new run's reports without the extension, so
+// that all formats share a name. A name already taken gets a numeric
+// suffix, e.g. for two runs within a second.
+func (a *Archive) Base(data NameData) (string, error) {
+	var buf bytes.Buffer
+	if err := a.name.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("report name: %w", err)
+	}
+	name := strings.Trim(unsafeName.ReplaceAllString(buf.String(), "-"), "-.")
+	if name == "" {
+		return "", fmt.Errorf("report name: %q is not a file name", buf.String())
+	}
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return "", err
+	}
+
+	unique := name
+	for i := 2; a.taken(unique); i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	return filepath.Join(a.dir, unique), nil
+}
+
+// taken reports whether a report could not be named name, because the
+// Archive uses the name itself or a file of that name exists.
+func (a *Archive) taken(name string) bool {
+	if name == latestName || name+".html" == indexFile {
+		return true
+	}
+	for _, ext := range reportExts {
+		if _, err := os.Lstat(filepath.Join(a.dir, name+ext)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish points the latest aliases at the reports just written, one path
+// per format, deletes the reports beyond the retention limits and rewrites
+// the index page.
+func (a *Archive) Publish(paths []string) (err error) {
+	manifest, err := a.readManifest()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if werr := a.writeManifest(manifest); err == nil {
+			err = werr
+		}
+	}()
+
+	current := ""
+	for _, path := range paths {
+		manifest[filepath.Base(path)] = true
+		if err := a.alias(manifest, path); err != nil {
+			return err
+		}
+		current = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	reports, err := a.prune(manifest, current)
+	if err != nil {
+		return err
+	}
+	if err := a.claim(manifest, indexFile); err != nil {
+		return err
+	}
+	return a.writeIndex(reports)
+}
+
+// claim records that the Archive writes file, which must not exist unless
+// the Archive wrote it before.
+func (a *Archive) claim(manifest map[string]bool, file string) error {
+	if manifest[file] {
+		return nil
+	}
+	if _, err := os.Lstat(filepath.Join(a.dir, file)); err == nil {
+		return fmt.Errorf("not overwriting %s, which was not written by DevTestrider", filepath.Join(a.dir, file))
+	}
+	manifest[file] = true
+	return nil
+}
+
+// alias makes latest.<ext> next to path a symlink to it, or a copy where
+// symlinks cannot be created, as on Windows without developer mode.
+func (a *Archive) alias(manifest map[string]bool, path string) error {
+	name := latestName + filepath.Ext(path)
+	if err := a.claim(manifest, name); err != nil {
+		return err
+	}
+	link := filepath.Join(a.dir, name)
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Symlink(filepath.Base(path), link); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(link)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// archived is a run's reports, one file per format.
+type archived struct {
+	Name  string
+	Files []string
+	// Modified is when the newest of the files was written.
+	Modified time.Time
+	Size     int64
+}
+
+// list returns the reports of the manifest, newest first. Files deleted by
+// hand are dropped from the manifest.
+func (a *Archive) list(manifest map[string]bool) []archived {
+	byName := make(map[string]*archived)
+	for file := range manifest {
+		ext := filepath.Ext(file)
+		name := strings.TrimSuffix(file, ext)
+		if name == latestName || file == indexFile {
+			continue
+		}
+		info, err := os.Lstat(filepath.Join(a.dir, file))
+		if err != nil {
+			delete(manifest, file)
+			continue
+		}
+		r := byName[name]
+		if r == nil {
+			r = &archived{Name: name}
+			byName[name] = r
+		}
+		r.Files = append(r.Files, file)
+		r.Size += info.Size()
+		if info.ModTime().After(r.Modified) {
+			r.Modified = info.ModTime()
+		}
+	}
+
+	reports := make([]archived, 0, len(byName))
+	for _, r := range byName {
+		sort.Strings(r.Files)
+		reports = append(reports, *r)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if !reports[i].Modified.Equal(reports[j].Modified) {
+			return reports[i].Modified.After(reports[j].Modified)
+		}
+		return reports[i].Name > reports[j].Name
+	})
+	return reports
+}
+
+// prune deletes the reports beyond the retention limits, never those named
+// current, and returns the rest, newest first.
+func (a *Archive) prune(manifest map[string]bool, current string) ([]archived, error) {
+	reports := a.list(manifest)
+
+	r := a.retention
+	limit := int64(r.MaxSizeMB) << 20
+	var kept []archived
+	var size int64
+	full := false
+	for _, report := range reports {
+		if report.Name != current {
+			full = full || limit > 0 && size+report.Size > limit
+			if full || r.Keep > 0 && len(kept) >= r.Keep || r.MaxAge > 0 && time.Since(report.Modified) > r.MaxAge {
+				for _, file := range report.Files {
+					if err := os.Remove(filepath.Join(a.dir, file)); err != nil && !os.IsNotExist(err) {
+						return nil, err
+					}
+					delete(manifest, file)
+				}
+				continue
+			}
+		}
+		kept = append(kept, report)
+		size += report.Size
+	}
+	return kept, nil
+}
+
+func (a *Archive) readManifest() (map[string]bool, error) {
+	manifest := make(map[string]bool)
+	data, err := os.ReadFile(filepath.Join(a.dir, manifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range strings.Split(string(data), "\n") {
+		// Names are plain file names in the directory; anything else is
+		// not ours to touch
+		if file != "" && !strings.HasPrefix(file, "#") && file == filepath.Base(file) && file != manifestFile {
+			manifest[file] = true
+		}
+	}
+	return manifest, nil
+}
+
+func (a *Archive) writeManifest(manifest map[string]bool) error {
+	files := make([]string, 0, len(manifest))
+	for file := range manifest {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	var buf bytes.Buffer
+	buf.WriteString("# Files written by DevTestrider; only these are replaced or deleted.\n")
+	for _, file := range files {
+		buf.WriteString(file + "\n")
+	}
+	return os.WriteFile(filepath.Join(a.dir, manifestFile), buf.Bytes(), 0644)
+}
+
+const indexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>DevTestrider Reports</title>
+    <style>
+        body { font-family: system-ui, -apple-system, sans-serif; background: #0f172a; color: #f8fafc; padding: 2rem; }
+        .container { max-width: 1000px; margin: 0 auto; }
+        .card { background: #1e293b; border-radius: 0.5rem; padding: 1.5rem; margin-bottom: 1rem; }
+        table { width: 100%; border-collapse: collapse; }
+        th, td { text-align: left; padding: 0.75rem 1rem; border-bottom: 1px solid #334155; }
+        th { color: #94a3b8; font-weight: 500; font-size: 0.875rem; }
+        a { color: #60a5fa; text-decoration: none; margin-right: 0.75rem; }
+        a:hover { text-decoration: underline; }
+        .muted { color: #64748b; font-size: 0.875rem; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>DevTestrider Reports</h1>
+        <p class="muted">{{len .Reports}} {{if eq (len .Reports) 1}}run{{else}}runs{{end}}, updated {{.Updated.Format "Jan 02, 2006 15:04:05"}}{{with .Latest}} · Latest: {{range .}}<a href="{{.}}">{{.}}</a>{{end}}{{end}}</p>
+        <div class="card">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Report</th>
+                        <th>Written</th>
+                        <th>Size</th>
+                        <th>Formats</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Reports}}
+                    <tr>
+                        <td>{{.Name}}</td>
+                        <td>{{.Modified.Format "Jan 02, 2006 15:04:05"}}</td>
+                        <td>{{size .Size}}</td>
+                        <td>{{range .Files}}<a href="{{.}}">{{ext .}}</a>{{end}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+// writeIndex writes the index page listing reports.
+func (a *Archive) writeIndex(reports []archived) error {
+	tmpl, err := template.New("index").Funcs(template.FuncMap{
+		"ext": func(file string) string { return strings.TrimPrefix(filepath.Ext(file), ".") },
+		"size": func(n int64) string {
+			if n < 1<<20 {
+				return fmt.Sprintf("%.0f KB", float64(n)/(1<<10))
+			}
+			return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+		},
+	}).Parse(indexTemplate)
+	if err != nil {
+		return err
+	}
+
+	var latest []string
+	for _, ext := range reportExts {
+		if _, err := os.Stat(filepath.Join(a.dir, latestName+ext)); err == nil {
+			latest = append(latest, latestName+ext)
+		}
+	}
+
+	var buf bytes.Buffer
+	data := struct {
+		Reports []archived
+		Latest  []string
+		Updated time.Time
+	}{reports, latest, time.Now()}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(a.dir, indexFile), buf.Bytes(), 0644)
+}
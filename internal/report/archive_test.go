@@ -0,0 +1,105 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+)
+
+// publish writes and publishes one run's reports in the given formats.
+func publish(t *testing.T, a *Archive, at time.Time, exts ...string) string {
+	t.Helper()
+	base, err := a.Base(NameData{Time: at.Format("20060102-150405"), Timestamp: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, ext := range exts {
+		if err := os.WriteFile(base+ext, []byte("report"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Retention orders runs by modification time
+		os.Chtimes(base+ext, at, at)
+		paths = append(paths, base+ext)
+	}
+	if err := a.Publish(paths); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Base(base)
+}
+
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestArchiveRetentionKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"README.md", "CHANGELOG.md", "guide.html", "report-old.pdf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("mine"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := NewArchive(config.ReportConfig{
+		OutputDir: dir,
+		Name:      "report-{{.Time}}",
+		Retention: config.RetentionConfig{Keep: 2, MaxAge: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(-3 * time.Hour)
+	var names []string
+	for i := range 4 {
+		names = append(names, publish(t, a, start.Add(time.Duration(i)*time.Hour), ".html", ".md"))
+	}
+
+	got := files(t, dir)
+	want := []string{
+		".devtestrider-reports", "CHANGELOG.md", "README.md", "guide.html", "index.html",
+		"latest.html", "latest.md", names[3] + ".html", names[3] + ".md", "report-old.pdf",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestArchiveDoesNotOverwriteForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("docs"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewArchive(config.ReportConfig{OutputDir: dir, Name: "report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := a.Base(NameData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(base+".html", []byte("report"), 0644)
+	if err := a.Publish([]string{base + ".html"}); err == nil {
+		t.Error("Publish replaced index.html, which it did not write")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "index.html")); string(data) != "docs" {
+		t.Errorf("index.html = %q, want it untouched", data)
+	}
+
+	// A report name that exists gets a suffix rather than replacing it
+	if base2, err := a.Base(NameData{}); err != nil || filepath.Base(base2) != "report-2" {
+		t.Errorf("Base = %q, %v, want report-2", base2, err)
+	}
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Size of a trend chart and the room left for its axis labels, in SVG
+// units.
+const (
+	chartWidth  = 440
+	chartHeight = 120
+	chartLeft   = 44
+	chartBottom = 18
+)
+
+// chart is an inline SVG line chart of values over the runs at times, so
+// that reports need no scripts or external files. format renders a value
+// for the axis and the tooltips.
+type chart struct {
+	Title  string
+	Color  string
+	Values []float64
+	Times  []time.Time
+	Format string
+}
+
+// SVG renders the chart. A single value is drawn as a dot.
+func (c chart) SVG() template.HTML {
+	vlo, vhi := c.Values[0], c.Values[0]
+	for _, v := range c.Values {
+		vlo, vhi = min(vlo, v), max(vhi, v)
+	}
+	lo, hi := vlo, vhi
+	if hi == lo {
+		// Flat lines sit in the middle
+		lo, hi = lo-1, hi+1
+	}
+
+	plotWidth := float64(chartWidth - chartLeft - 8)
+	plotHeight := float64(chartHeight - chartBottom - 8)
+	x := func(i int) float64 {
+		if len(c.Values) == 1 {
+			return chartLeft + plotWidth/2
+		}
+		return chartLeft + plotWidth*float64(i)/float64(len(c.Values)-1)
+	}
+	y := func(v float64) float64 {
+		return 8 + plotHeight*(hi-v)/(hi-lo)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s">`, chartWidth, chartHeight, html.EscapeString(c.Title))
+	// Axis labels for the range and the first and last run
+	fmt.Fprintf(&b, `<text x="%d" y="%.1f" class="axis" text-anchor="end" dominant-baseline="middle">%s</text>`, chartLeft-6, y(vhi), c.format(vhi))
+	if vlo != vhi {
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" class="axis" text-anchor="end" dominant-baseline="middle">%s</text>`, chartLeft-6, y(vlo), c.format(vlo))
+	}
+	fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" class="grid"/>`, chartLeft, y(lo), chartWidth-8, y(lo))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" class="axis">%s</text>`, chartLeft, chartHeight-4, c.Times[0].Format("Jan 02 15:04"))
+	if len(c.Times) > 1 {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" class="axis" text-anchor="end">%s</text>`, chartWidth-8, chartHeight-4, c.Times[len(c.Times)-1].Format("Jan 02 15:04"))
+	}
+
+	points := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		points[i] = fmt.Sprintf("%.1f,%.1f", x(i), y(v))
+	}
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), c.Color)
+	for i, v := range c.Values {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s: %s</title></circle>`,
+			x(i), y(v), c.Color, c.Times[i].Format("Jan 02 15:04:05"), c.format(v))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func (c chart) format(v float64) string {
+	return html.EscapeString(fmt.Sprintf(c.Format, v))
+}
@@ -5,8 +5,11 @@ import (
 	"html/template"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/ismailtsdln/DevTestrider/internal/analytics"
 	"github.com/ismailtsdln/DevTestrider/internal/engine"
 )
 
@@ -34,6 +37,26 @@ const htmlTemplate = `
         .badge { padding: 0.25rem 0.5rem; border-radius: 0.25rem; font-size: 0.75rem; font-weight: 600; }
         .badge-pass { background: rgba(52, 211, 153, 0.1); color: #34d399; }
         .badge-fail { background: rgba(244, 63, 94, 0.1); color: #f43f5e; }
+        .badge-skip { background: rgba(251, 191, 36, 0.1); color: #fbbf24; }
+        details { margin: 0.25rem 0; }
+        details details, details .test { margin-left: 1.25rem; }
+        summary { cursor: pointer; padding: 0.375rem 0; }
+        .package-summary { font-weight: 600; }
+        .test { padding: 0.375rem 0; font-family: ui-monospace, monospace; font-size: 0.875rem; }
+        details details > summary { font-family: ui-monospace, monospace; font-size: 0.875rem; }
+        .muted { color: #64748b; font-size: 0.875rem; }
+        .build-error { font-family: ui-monospace, monospace; font-size: 0.875rem; color: #fda4af; margin-left: 1.25rem; padding: 0.25rem 0; }
+        .charts { display: grid; grid-template-columns: repeat(2, 1fr); gap: 1rem; }
+        figure { margin: 0; }
+        figcaption { color: #94a3b8; font-size: 0.875rem; margin-bottom: 0.25rem; }
+        svg.chart { width: 100%; height: auto; }
+        svg.chart .axis { fill: #64748b; font-size: 10px; }
+        svg.chart .grid { stroke: #334155; }
+        ul.tests { margin: 0.5rem 0 1rem; padding-left: 1.25rem; font-family: ui-monospace, monospace; font-size: 0.875rem; }
+        .output-summary { font-family: ui-monospace, monospace; font-size: 0.875rem; }
+        h4.package { margin: 1rem 0 0.25rem; font-size: 0.95rem; }
+        td.location { font-family: ui-monospace, monospace; font-size: 0.875rem; white-space: nowrap; }
+        pre.stack { background: #0f172a; color: #cbd5e1; padding: 0.75rem; border-radius: 0.375rem; overflow-x: auto; font-size: 0.75rem; margin-left: 1.25rem; }
     </style>
 </head>
 <body>
@@ -55,7 +78,7 @@ const htmlTemplate = `
         <div class="stats">
             <div class="stat-box">
                 <span class="stat-value">{{.TotalTests}}</span>
-                <span class="stat-label">Total Tests</span>
+                <span class="stat-label">Total Tests{{if .ParentTests}} (+{{.ParentTests}} with subtests){{end}}</span>
             </div>
             <div class="stat-box">
                 <span class="stat-value success">{{.PassedTests}}</span>
@@ -87,49 +110,334 @@ const htmlTemplate = `
                     {{range .Packages}}
                     <tr>
                         <td>{{.Name}}</td>
-                        <td>{{len .Tests}}</td>
+                        <td>{{len .Leaves}}</td>
                         <td>{{if gt .Coverage 0.0}}{{printf "%.1f%%" .Coverage}}{{else}}-{{end}}</td>
                         <td>{{printf "%.3f" .Duration}}s</td>
                         <td>
-                            {{if eq .Status "PASS"}}
-                                <span class="badge badge-pass">PASS</span>
-                            {{else}}
-                                <span class="badge badge-fail">FAIL</span>
-                            {{end}}
+                            {{template "badge" .Status}}
                         </td>
                     </tr>
                     {{end}}
                 </tbody>
             </table>
         </div>
+
+        {{if .Charts}}
+        <div class="card">
+            <h3>Trends</h3>
+            <p class="muted">The suite after each of the last {{len .Trend}} runs; packages a run did not test keep their last result.</p>
+            <div class="charts">
+                {{range .Charts}}
+                <figure>
+                    <figcaption>{{.Title}}</figcaption>
+                    {{.SVG}}
+                </figure>
+                {{end}}
+            </div>
+        </div>
+        {{end}}
+
+        {{with .Changes}}
+        <div class="card">
+            <h3>Since the Previous Run</h3>
+            {{if .NewlyFailing}}
+            <h4 class="failure">Newly failing</h4>
+            <ul class="tests">{{range .NewlyFailing}}<li>{{template "ref" .}}</li>{{end}}</ul>
+            {{end}}
+            {{if .NewlyPassing}}
+            <h4 class="success">Newly passing</h4>
+            <ul class="tests">{{range .NewlyPassing}}<li>{{template "ref" .}}</li>{{end}}</ul>
+            {{end}}
+            {{if not (or .NewlyFailing .NewlyPassing)}}
+            <p class="muted">No test changed between passing and failing.</p>
+            {{end}}
+            {{if .Coverage}}
+            <table>
+                <thead>
+                    <tr>
+                        <th>Package</th>
+                        <th>Previous Coverage</th>
+                        <th>Coverage</th>
+                        <th>Change</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Coverage}}
+                    <tr>
+                        <td>{{.Package}}</td>
+                        <td>{{printf "%.1f%%" .Previous}}</td>
+                        <td>{{printf "%.1f%%" .Current}}</td>
+                        <td class="{{if gt .Delta 0.05}}success{{else if lt .Delta -0.05}}failure{{else}}muted{{end}}">{{printf "%+.1f" .Delta}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+            {{end}}
+        </div>
+        {{end}}
+
+        {{with .Slow}}
+        {{if .Regressions}}
+        <div class="card">
+            <h3>Duration Regressions</h3>
+            <p class="muted">Tests running more than {{printf "%g" .RegressionFactor}}× slower than their median over the last {{.Runs}} runs.</p>
+            {{template "timings" .Regressions}}
+        </div>
+        {{end}}
+        {{if .Slowest}}
+        <div class="card">
+            <h3>Slowest Tests</h3>
+            {{template "timings" .Slowest}}
+        </div>
+        {{end}}
+        {{if .Packages}}
+        <div class="card">
+            <h3>Package Durations</h3>
+            {{template "timings" .Packages}}
+        </div>
+        {{end}}
+        {{end}}
+
+        {{if .Details}}
+        <div class="card">
+            <h3>Failing and Skipped Tests</h3>
+            {{range .Details}}
+            <h4 class="package">{{.Package.Name}}</h4>
+            {{range .Tests}}
+            <details{{if eq .Status "FAIL"}} open{{end}}>
+                <summary class="output-summary">{{template "badge" .Status}} {{.Name}}{{with testLocation .}} <span class="muted">{{.}}</span>{{end}}</summary>
+                {{if .Output}}<pre class="stack">{{range .Output}}{{.}}
+{{end}}</pre>{{else}}<p class="muted">No output.</p>{{end}}
+            </details>
+            {{end}}
+            {{end}}
+        </div>
+        {{end}}
+
+        {{with vetIssues .Issues}}
+        <div class="card">
+            <h3>Vet Issues</h3>
+            <table>
+                <thead>
+                    <tr>
+                        <th>Location</th>
+                        <th>Issue</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .}}
+                    <tr>
+                        <td class="location">{{if .File}}{{.File}}:{{.Line}}{{else}}-{{end}}</td>
+                        <td>{{.Message}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        <div class="card">
+            <h3>Tests</h3>
+            {{range .Packages}}
+            <details{{if .Failed}} open{{end}}>
+                <summary class="package-summary">{{.Name}} <span class="muted">{{len .Leaves}} tests</span>{{if and .Failed (ne .Status "FAIL")}} {{template "badge" .Status}}{{end}}</summary>
+                {{range .BuildErrors}}
+                <div class="build-error">{{.File}}:{{.Line}}: {{.Message}}</div>
+                {{end}}
+                {{if .Stack}}<pre class="stack">{{range .Stack}}{{.}}
+{{end}}</pre>{{end}}
+                {{template "tests" sortTests .Tests}}
+            </details>
+            {{end}}
+        </div>
     </div>
 </body>
 </html>
+{{define "badge"}}<span class="badge {{if eq . "PASS"}}badge-pass{{else if eq . "SKIP"}}badge-skip{{else}}badge-fail{{end}}">{{statusLabel .}}</span>{{end}}
+{{define "ref"}}{{if .Test}}{{.Test}} <span class="muted">{{.Package}}</span>{{else}}{{.Package}} <span class="muted">(package)</span>{{end}}{{end}}
+{{define "timings"}}
+            <table>
+                <thead>
+                    <tr>
+                        <th>{{if (index . 0).Test}}Test{{else}}Package{{end}}</th>
+                        <th>Duration</th>
+                        <th>Median</th>
+                        <th>p95</th>
+                        <th>vs. Median</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .}}
+                    <tr>
+                        <td>{{if .Test}}{{.Test}} <span class="muted">{{.Package}}</span>{{else}}{{.Package}}{{end}}</td>
+                        <td>{{printf "%.3f" .Duration}}s</td>
+                        {{if .Samples}}
+                        <td>{{printf "%.3f" .Median}}s</td>
+                        <td>{{printf "%.3f" .P95}}s</td>
+                        <td>{{if .Ratio}}{{printf "%.1f" .Ratio}}×{{else}}-{{end}}</td>
+                        {{else}}
+                        <td>-</td>
+                        <td>-</td>
+                        <td><span class="muted">new</span></td>
+                        {{end}}
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+{{end}}
+{{define "tests"}}{{range .}}
+    {{if .Leaf}}
+    <div class="test">{{template "badge" .Status}} {{.ShortName}} <span class="muted">{{printf "%.3f" .Duration}}s</span></div>
+    {{else}}
+    <details{{if eq .Status "FAIL"}} open{{end}}>
+        <summary>{{template "badge" .Status}} {{.ShortName}} <span class="muted">{{len .Subtests}} {{if eq (len .Subtests) 1}}subtest{{else}}subtests{{end}}, {{printf "%.3f" .Duration}}s</span></summary>
+        {{template "tests" sortTests .Subtests}}
+    </details>
+    {{end}}
+{{end}}{{end}}
 `
 
-func GenerateHTML(result *engine.TestResult, outputDir string) (string, error) {
-	if outputDir == "" {
-		outputDir = "."
+// statusLabel names a package or test status for display.
+func statusLabel(status string) string {
+	switch status {
+	case engine.StatusBuildFailed:
+		return "BUILD FAILED"
+	case engine.StatusTimeout:
+		return "TIMED OUT"
+	}
+	return status
+}
+
+// sortTests orders one level of the test tree by name.
+func sortTests(tests []*engine.TestCase) []*engine.TestCase {
+	sorted := append([]*engine.TestCase(nil), tests...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// packageDetails are the tests of a package whose output a report shows.
+type packageDetails struct {
+	Package *engine.PackageResult
+	Tests   []*engine.TestCase
+}
+
+// details lists, package by package, the tests that failed themselves and
+// those that were skipped. Packages without any are left out.
+func details(result *engine.TestResult) []packageDetails {
+	var list []packageDetails
+	for _, name := range packageNames(result) {
+		pkg := result.Packages[name]
+		failed := make(map[*engine.TestCase]bool)
+		for _, test := range pkg.Failures() {
+			failed[test] = true
+		}
+		var tests []*engine.TestCase
+		for _, test := range pkg.AllTests() {
+			if failed[test] || test.Status == "SKIP" {
+				tests = append(tests, test)
+			}
+		}
+		if len(tests) > 0 {
+			list = append(list, packageDetails{Package: pkg, Tests: tests})
+		}
+	}
+	return list
+}
+
+// testLocation is the file:line a test's output points at, if any.
+func testLocation(test *engine.TestCase) string {
+	file, line, ok := test.Location()
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// vetIssues splits "go vet" lines into location and message. Lines without
+// a location are kept whole as the message.
+func vetIssues(issues []string) []engine.ValidationIssue {
+	parsed := make([]engine.ValidationIssue, len(issues))
+	for i, issue := range issues {
+		if v, ok := engine.ParseIssue(issue); ok {
+			parsed[i] = v
+		} else {
+			parsed[i] = engine.ValidationIssue{Message: strings.TrimSpace(issue)}
+		}
+	}
+	return parsed
+}
+
+func packageNames(result *engine.TestResult) []string {
+	names := make([]string, 0, len(result.Packages))
+	for name := range result.Packages {
+		names = append(names, name)
 	}
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", err
+	sort.Strings(names)
+	return names
+}
+
+// htmlData is what the HTML template renders: the run and what is known
+// about it from earlier runs.
+type htmlData struct {
+	*engine.TestResult
+	Details []packageDetails
+	Slow    *analytics.SlowReport
+	Trend   []analytics.Point
+	Charts  []chart
+	Changes *analytics.Comparison
+}
+
+func newHTMLData(result *engine.TestResult, insights Insights) htmlData {
+	data := htmlData{TestResult: result, Details: details(result), Slow: insights.Slow}
+	if len(insights.History) == 0 {
+		return data
 	}
 
-	filename := filepath.Join(outputDir, fmt.Sprintf("report-%d.html", time.Now().Unix()))
-	f, err := os.Create(filename)
+	data.Trend = analytics.Trend(append(insights.History[:len(insights.History):len(insights.History)], result))
+	times := make([]time.Time, len(data.Trend))
+	series := map[string][]float64{}
+	for i, p := range data.Trend {
+		times[i] = p.Timestamp
+		series["pass"] = append(series["pass"], p.PassRate)
+		series["tests"] = append(series["tests"], float64(p.Tests))
+		series["coverage"] = append(series["coverage"], p.Coverage)
+		series["duration"] = append(series["duration"], p.Duration)
+	}
+	data.Charts = []chart{
+		{Title: "Pass rate", Color: "#34d399", Values: series["pass"], Times: times, Format: "%.0f%%"},
+		{Title: "Tests", Color: "#60a5fa", Values: series["tests"], Times: times, Format: "%.0f"},
+		{Title: "Mean coverage", Color: "#fbbf24", Values: series["coverage"], Times: times, Format: "%.1f%%"},
+		{Title: "Duration", Color: "#a78bfa", Values: series["duration"], Times: times, Format: "%.2fs"},
+	}
+	if prev := analytics.Previous(insights.History, result); prev != nil {
+		data.Changes = analytics.Compare(prev, result)
+	}
+	return data
+}
+
+// GenerateHTML writes a self-contained HTML report of result to path.
+func GenerateHTML(result *engine.TestResult, insights Insights, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer f.Close()
 
-	tmpl, err := template.New("report").Parse(htmlTemplate)
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"sortTests":    sortTests,
+		"statusLabel":  statusLabel,
+		"testLocation": testLocation,
+		"vetIssues":    vetIssues,
+	}).Parse(htmlTemplate)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	if err := tmpl.Execute(f, result); err != nil {
-		return "", err
+	if err := tmpl.Execute(f, newHTMLData(result, insights)); err != nil {
+		return err
 	}
-
-	return filename, nil
+	return f.Close()
 }
@@ -0,0 +1,183 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Failing tests listed in a Markdown report; pull request comments are
+// limited in size.
+const maxMarkdownFailures = 25
+
+// backticks matches runs of backticks, which a code fence must outnumber.
+var backticks = regexp.MustCompile("`+")
+
+// Markdown renders a compact summary of result for pull request comments
+// and CI step summaries. With a baseline, coverage is compared with it.
+// Failing tests show the last outputLines lines of their output.
+func Markdown(result *engine.TestResult, baseline *engine.TestResult, outputLines int) string {
+	var b strings.Builder
+
+	if result.Success {
+		b.WriteString("## ✅ Tests passed\n\n")
+	} else {
+		b.WriteString("## ❌ Tests failed\n\n")
+	}
+	fmt.Fprintf(&b, "| Tests | Passed | Failed | Skipped | Packages | Duration |\n")
+	fmt.Fprintf(&b, "| ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | %.2fs |\n\n",
+		result.TotalTests, result.PassedTests, result.FailedTests, result.SkippedTests, len(result.Packages), result.Duration)
+
+	markdownFailures(&b, result, outputLines)
+	markdownCoverage(&b, result, baseline)
+
+	if issues := vetIssues(result.Issues); len(issues) > 0 {
+		fmt.Fprintf(&b, "### Vet issues (%d)\n\n", len(issues))
+		b.WriteString("| Location | Issue |\n| --- | --- |\n")
+		for _, issue := range issues {
+			location := "-"
+			if issue.File != "" {
+				location = fmt.Sprintf("`%s:%d`", issue.File, issue.Line)
+			}
+			fmt.Fprintf(&b, "| %s | %s |\n", location, cell(issue.Message))
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "<sub>DevTestrider · %s</sub>\n", result.Timestamp.Format("Jan 02, 2006 15:04:05"))
+	return b.String()
+}
+
+// markdownFailures lists the packages that failed without a failing test
+// and the failing tests, each with its output folded away.
+func markdownFailures(b *strings.Builder, result *engine.TestResult, outputLines int) {
+	type failure struct {
+		summary string
+		lines   []string
+	}
+	var failures []failure
+	for _, name := range packageNames(result) {
+		pkg := result.Packages[name]
+		if !pkg.Failed() {
+			continue
+		}
+		tests := pkg.Failures()
+		// A package that failed on its own, e.g. did not build, gets its own
+		// entry unless the test that panicked already shows the stack
+		if len(tests) == 0 || pkg.Status != "FAIL" && !carriesStack(pkg, tests) {
+			var lines []string
+			for _, err := range pkg.BuildErrors {
+				lines = append(lines, fmt.Sprintf("%s:%d: %s", err.File, err.Line, err.Message))
+			}
+			lines = append(lines, pkg.Stack...)
+			failures = append(failures, failure{fmt.Sprintf("<code>%s</code> %s", escape(name), statusLabel(pkg.Status)), lines})
+		}
+		for _, test := range tests {
+			summary := fmt.Sprintf("<code>%s</code> in <code>%s</code>", escape(test.Name), escape(name))
+			if loc := testLocation(test); loc != "" {
+				summary += fmt.Sprintf(" at <code>%s</code>", escape(loc))
+			}
+			failures = append(failures, failure{summary, test.Output})
+		}
+	}
+	if len(failures) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "### Failures (%d)\n\n", len(failures))
+	for i, f := range failures {
+		if i == maxMarkdownFailures {
+			fmt.Fprintf(b, "…and %d more.\n\n", len(failures)-i)
+			break
+		}
+		fmt.Fprintf(b, "<details><summary>%s</summary>\n\n", f.summary)
+		lines := f.lines
+		if outputLines > 0 && len(lines) > outputLines {
+			lines = append([]string{fmt.Sprintf("… %d earlier lines", len(lines)-outputLines)}, lines[len(lines)-outputLines:]...)
+		}
+		if len(lines) == 0 {
+			b.WriteString("No output.\n\n</details>\n\n")
+			continue
+		}
+		text := strings.Join(lines, "\n")
+		fence := "```"
+		for _, run := range backticks.FindAllString(text, -1) {
+			if len(run) >= len(fence) {
+				fence = strings.Repeat("`", len(run)+1)
+			}
+		}
+		fmt.Fprintf(b, "%s\n%s\n%s\n\n</details>\n\n", fence, text, fence)
+	}
+}
+
+// carriesStack reports whether the output of one of tests includes pkg's
+// panic, as that of the test that panicked does.
+func carriesStack(pkg *engine.PackageResult, tests []*engine.TestCase) bool {
+	if len(pkg.Stack) == 0 {
+		return false
+	}
+	for _, test := range tests {
+		if slices.Contains(test.Output, pkg.Stack[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// markdownCoverage lists the coverage of the packages that report one,
+// with the change since baseline if there is one.
+func markdownCoverage(b *strings.Builder, result *engine.TestResult, baseline *engine.TestResult) {
+	var rows []string
+	for _, name := range packageNames(result) {
+		pkg := result.Packages[name]
+		if pkg.Coverage <= 0 {
+			continue
+		}
+		row := fmt.Sprintf("| `%s` | %.1f%% |", name, pkg.Coverage)
+		if baseline != nil {
+			if before, ok := baseline.Packages[name]; ok {
+				row += fmt.Sprintf(" %s |", delta(pkg.Coverage-before.Coverage))
+			} else {
+				row += " new |"
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	b.WriteString("### Coverage\n\n")
+	if baseline != nil {
+		b.WriteString("| Package | Coverage | Change |\n| --- | ---: | ---: |\n")
+	} else {
+		b.WriteString("| Package | Coverage |\n| --- | ---: |\n")
+	}
+	b.WriteString(strings.Join(rows, "\n"))
+	b.WriteString("\n\n")
+}
+
+// delta formats a coverage change, marking drops.
+func delta(d float64) string {
+	switch {
+	case d <= -0.05:
+		return fmt.Sprintf("🔻 %.1f", d)
+	case d >= 0.05:
+		return fmt.Sprintf("+%.1f", d)
+	}
+	return "±0"
+}
+
+// cell makes text safe in a table cell.
+func cell(text string) string {
+	return strings.ReplaceAll(escape(text), "|", `\|`)
+}
+
+// escape makes text safe in HTML, which Markdown passes through.
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
@@ -0,0 +1,32 @@
+package report
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+func TestMarkdownPanicListedOnce(t *testing.T) {
+	f, err := os.Open("testdata/panic.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := engine.NewRunner().Ingest(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := result.Packages["panicfx"].Status; status != engine.StatusPanic {
+		t.Fatalf("package status = %s, want %s", status, engine.StatusPanic)
+	}
+
+	md := Markdown(result, nil, 0)
+	if !strings.Contains(md, "### Failures (1)") {
+		t.Errorf("want one failure, the test that panicked:\n%s", md)
+	}
+	if n := strings.Count(md, "goroutine 8 [running]"); n != 1 {
+		t.Errorf("stack shown %d times, want once:\n%s", n, md)
+	}
+}
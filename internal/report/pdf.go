@@ -4,22 +4,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"strings"
 
 	"github.com/johnfercher/maroto/pkg/color"
 	"github.com/johnfercher/maroto/pkg/consts"
 	"github.com/johnfercher/maroto/pkg/pdf"
 	"github.com/johnfercher/maroto/pkg/props"
 
+	"github.com/ismailtsdln/DevTestrider/internal/analytics"
 	"github.com/ismailtsdln/DevTestrider/internal/engine"
 )
 
-func GeneratePDF(result *engine.TestResult, outputDir string) (string, error) {
-	if outputDir == "" {
-		outputDir = "."
-	}
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", err
+// GeneratePDF writes a PDF report of result to path. Of the insights only
+// the slow-test sections are shown.
+func GeneratePDF(result *engine.TestResult, insights Insights, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
 	}
 
 	m := pdf.NewMaroto(consts.Portrait, consts.A4)
@@ -78,26 +78,18 @@ func GeneratePDF(result *engine.TestResult, outputDir string) (string, error) {
 		m.Col(3, func() { m.Text(fmt.Sprintf("Duration: %.2fs", result.Duration), props.Text{}) })
 	})
 
-	m.Row(10, func() {
-		m.Col(12, func() {
-			m.Text("Package Details", props.Text{Style: consts.Bold, Size: 12, Top: 5})
-		})
-	})
-
-	// Header Row
-	m.Row(10, func() {
+	packages := &table{m: m, title: "Package Details", height: 10, header: func() {
 		m.Col(6, func() { m.Text("Package", props.Text{Style: consts.Bold}) })
 		m.Col(2, func() { m.Text("Status", props.Text{Style: consts.Bold}) })
 		m.Col(2, func() { m.Text("Coverage", props.Text{Style: consts.Bold}) })
 		m.Col(2, func() { m.Text("Duration", props.Text{Style: consts.Bold}) })
-	})
+	}}
 
-	for _, pkg := range result.Packages {
+	names := packageNames(result)
+	for _, name := range names {
+		pkg := result.Packages[name]
 		pkgName := pkg.Name
-		pkgStatus := "FAIL"
-		if pkg.Status == "PASS" {
-			pkgStatus = "PASS"
-		}
+		pkgStatus := statusLabel(pkg.Status)
 
 		cov := "-"
 		if pkg.Coverage > 0 {
@@ -106,7 +98,7 @@ func GeneratePDF(result *engine.TestResult, outputDir string) (string, error) {
 
 		duration := fmt.Sprintf("%.3fs", pkg.Duration)
 
-		m.Row(8, func() {
+		packages.Row(8, func() {
 			m.Col(6, func() { m.Text(pkgName, props.Text{Size: 9}) })
 			m.Col(2, func() { m.Text(pkgStatus, props.Text{Size: 9}) })
 			m.Col(2, func() { m.Text(cov, props.Text{Size: 9}) })
@@ -114,10 +106,265 @@ func GeneratePDF(result *engine.TestResult, outputDir string) (string, error) {
 		})
 	}
 
-	filename := filepath.Join(outputDir, fmt.Sprintf("report-%d.pdf", time.Now().Unix()))
-	if err := m.OutputFileAndClose(filename); err != nil {
-		return "", err
+	if slow := insights.Slow; slow != nil {
+		if len(slow.Regressions) > 0 {
+			timingTable(m, fmt.Sprintf("Duration Regressions (over %gx the median of %d runs)", slow.RegressionFactor, slow.Runs), slow.Regressions)
+		}
+		timingTable(m, "Slowest Tests", slow.Slowest)
+	}
+
+	title := "Failing and Skipped Tests"
+	for _, d := range details(result) {
+		testOutput(m, title, d)
+		title = ""
+	}
+
+	if len(result.Issues) > 0 {
+		vetTable(m, result.Issues)
+	}
+
+	title = "Test Details"
+	for _, name := range names {
+		testTree(m, title, result.Packages[name])
+		title = ""
+	}
+
+	return m.OutputFileAndClose(path)
+}
+
+// Lines of a panic's stack and of a test's output shown in the PDF, and
+// the number of characters their lines are wrapped at.
+const (
+	pdfStackLines  = 12
+	pdfOutputLines = 40
+	pdfOutputWidth = 110
+)
+
+// table draws rows under a title and a column header, moving to a new page
+// rather than leaving either alone at the bottom of one. The header is
+// repeated on every page the rows spill onto.
+type table struct {
+	m pdf.Maroto
+	// title is shown once above the header, if not empty.
+	title   string
+	height  float64
+	header  func()
+	started bool
+}
+
+// start draws the title and the header, followed by room for a row of the
+// given height.
+func (t *table) start(height float64) {
+	t.started = true
+	need := t.height + height
+	if t.title != "" {
+		need += 10
+	}
+	if !fits(t.m, need) {
+		t.m.AddPage()
+	}
+	if t.title != "" {
+		t.m.Row(10, func() {
+			t.m.Col(12, func() {
+				t.m.Text(t.title, props.Text{Style: consts.Bold, Size: 12, Top: 5})
+			})
+		})
+	}
+	t.m.Row(t.height, t.header)
+}
+
+// Row draws a row of the table.
+func (t *table) Row(height float64, closure func()) {
+	if !t.started {
+		t.start(height)
+	} else if !fits(t.m, height) {
+		t.m.AddPage()
+		t.m.Row(t.height, t.header)
+	}
+	t.m.Row(height, closure)
+}
+
+// fits reports whether a row of the given height fits on the current page.
+// Like maroto, it leaves out the space above the first row of a page.
+func fits(m pdf.Maroto, height float64) bool {
+	if m.GetCurrentOffset() == 0 {
+		return true
+	}
+	_, pageHeight := m.GetPageSize()
+	_, top, _, bottom := m.GetPageMargins()
+	return int(m.GetCurrentOffset()+height) <= int(pageHeight-bottom-top)
+}
+
+// wrap splits line into pieces of at most width characters, breaking after
+// a space where one comes late enough. Tabs become four spaces.
+func wrap(line string, width int) []string {
+	runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+	var lines []string
+	for len(runes) > width {
+		cut := width
+		for i := width - 1; i > width/2; i-- {
+			if runes[i] == ' ' {
+				cut = i + 1
+				break
+			}
+		}
+		lines = append(lines, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	return append(lines, string(runes))
+}
+
+// monospace draws lines as wrapped Courier rows indented by left.
+func monospace(t *table, lines []string, left float64) {
+	for _, line := range lines {
+		for _, part := range wrap(line, pdfOutputWidth) {
+			t.Row(4, func() {
+				t.m.Col(12, func() { t.m.Text(part, props.Text{Size: 7, Family: consts.Courier, Left: left}) })
+			})
+		}
+	}
+}
+
+// testTree lists the tests of pkg indented by depth, under title if it is
+// the first package. Paper cannot fold, so the subtests of passing parents
+// are collapsed into the parent's row and only failing branches are shown
+// in full.
+func testTree(m pdf.Maroto, title string, pkg *engine.PackageResult) {
+	t := &table{m: m, title: title, height: 8, header: func() {
+		m.Col(12, func() { m.Text(pkg.Name, props.Text{Size: 9, Style: consts.Bold, Top: 2}) })
+	}}
+	t.start(6)
+
+	red := color.Color{Red: 200, Green: 0, Blue: 0}
+	for _, err := range pkg.BuildErrors {
+		text := fmt.Sprintf("%s:%d: %s", err.File, err.Line, err.Message)
+		t.Row(6, func() {
+			m.Col(12, func() { m.Text(text, props.Text{Size: 8, Color: red, Left: 4}) })
+		})
+	}
+	// The panic message and the first frames; the rest is in the test output
+	stack := pkg.Stack
+	if len(stack) > pdfStackLines {
+		stack = stack[:pdfStackLines]
+	}
+	monospace(t, stack, 4)
+
+	collapsed := ""
+	for _, test := range pkg.AllTests() {
+		if collapsed != "" && strings.HasPrefix(test.Name, collapsed+"/") {
+			continue
+		}
+		collapsed = ""
+
+		label := test.ShortName()
+		indent := float64(test.Depth()+1) * 4
+		if !test.Leaf() {
+			if len(test.Subtests) == 1 {
+				label += " (1 subtest)"
+			} else {
+				label += fmt.Sprintf(" (%d subtests)", len(test.Subtests))
+			}
+			if test.Status != "FAIL" {
+				collapsed = test.Name
+			}
+		}
+		status := test.Status
+		duration := fmt.Sprintf("%.3fs", test.Duration)
+		textColor := color.Color{Red: 0, Green: 0, Blue: 0}
+		if status == "FAIL" {
+			textColor = color.Color{Red: 200, Green: 0, Blue: 0}
+		}
+
+		t.Row(6, func() {
+			m.Col(8, func() { m.Text(label, props.Text{Size: 8, Color: textColor, Left: indent}) })
+			m.Col(2, func() { m.Text(status, props.Text{Size: 8, Color: textColor}) })
+			m.Col(2, func() { m.Text(duration, props.Text{Size: 8}) })
+		})
 	}
+}
 
-	return filename, nil
+// testOutput lists the failing and skipped tests of a package with the end
+// of their output, under title if it is the first package.
+func testOutput(m pdf.Maroto, title string, d packageDetails) {
+	t := &table{m: m, title: title, height: 8, header: func() {
+		m.Col(12, func() { m.Text(d.Package.Name, props.Text{Size: 9, Style: consts.Bold, Top: 2}) })
+	}}
+	gray := color.Color{Red: 100, Green: 100, Blue: 100}
+	for _, test := range d.Tests {
+		label := statusLabel(test.Status) + "  " + test.Name
+		if loc := testLocation(test); loc != "" {
+			label += "  " + loc
+		}
+		labelColor := color.Color{Red: 200, Green: 0, Blue: 0}
+		if test.Status == "SKIP" {
+			labelColor = gray
+		}
+		t.Row(6, func() {
+			m.Col(12, func() { m.Text(label, props.Text{Size: 8, Style: consts.Bold, Color: labelColor, Left: 4, Top: 1}) })
+		})
+
+		lines := test.Output
+		if len(lines) > pdfOutputLines {
+			note := fmt.Sprintf("... %d earlier lines", len(lines)-pdfOutputLines)
+			t.Row(4, func() {
+				m.Col(12, func() { m.Text(note, props.Text{Size: 7, Color: gray, Left: 8}) })
+			})
+			lines = lines[len(lines)-pdfOutputLines:]
+		}
+		monospace(t, lines, 8)
+	}
+}
+
+// vetTable lists the "go vet" issues of the run.
+func vetTable(m pdf.Maroto, issues []string) {
+	t := &table{m: m, title: "Vet Issues", height: 8, header: func() {
+		m.Col(4, func() { m.Text("Location", props.Text{Style: consts.Bold, Size: 9}) })
+		m.Col(8, func() { m.Text("Issue", props.Text{Style: consts.Bold, Size: 9}) })
+	}}
+	for _, issue := range vetIssues(issues) {
+		location := "-"
+		if issue.File != "" {
+			location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+		}
+		for i, line := range wrap(issue.Message, 80) {
+			if i > 0 {
+				location = ""
+			}
+			t.Row(5, func() {
+				m.Col(4, func() { m.Text(location, props.Text{Size: 8}) })
+				m.Col(8, func() { m.Text(line, props.Text{Size: 8}) })
+			})
+		}
+	}
+}
+
+// timingTable lists test durations against their baselines.
+func timingTable(m pdf.Maroto, title string, timings []analytics.Timing) {
+	if len(timings) == 0 {
+		return
+	}
+	t := &table{m: m, title: title, height: 8, header: func() {
+		m.Col(6, func() { m.Text("Test", props.Text{Style: consts.Bold, Size: 9}) })
+		m.Col(2, func() { m.Text("Duration", props.Text{Style: consts.Bold, Size: 9}) })
+		m.Col(2, func() { m.Text("Median / p95", props.Text{Style: consts.Bold, Size: 9}) })
+		m.Col(2, func() { m.Text("vs. Median", props.Text{Style: consts.Bold, Size: 9}) })
+	}}
+	for _, timing := range timings {
+		name := timing.Package[strings.LastIndex(timing.Package, "/")+1:] + "." + timing.Test
+		duration := fmt.Sprintf("%.3fs", timing.Duration)
+		baseline, ratio := "-", "new"
+		if timing.Samples > 0 {
+			baseline = fmt.Sprintf("%.3fs / %.3fs", timing.Median, timing.P95)
+			ratio = "-"
+		}
+		if timing.Ratio > 0 {
+			ratio = fmt.Sprintf("%.1fx", timing.Ratio)
+		}
+		t.Row(6, func() {
+			m.Col(6, func() { m.Text(name, props.Text{Size: 8}) })
+			m.Col(2, func() { m.Text(duration, props.Text{Size: 8}) })
+			m.Col(2, func() { m.Text(baseline, props.Text{Size: 8}) })
+			m.Col(2, func() { m.Text(ratio, props.Text{Size: 8}) })
+		})
+	}
 }
@@ -0,0 +1,151 @@
+// Package report writes HTML, PDF, Markdown and JSON reports of test runs and
+// manages the directory they are kept in.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/ismailtsdln/DevTestrider/internal/analytics"
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/git"
+	"github.com/ismailtsdln/DevTestrider/internal/history"
+)
+
+// Insights is what a report shows beyond the run itself. Every field is
+// optional.
+type Insights struct {
+	// Slow adds the slow-test sections.
+	Slow *analytics.SlowReport
+	// History are earlier runs, oldest first. The HTML report charts them
+	// with the run and lists what changed since the previous one.
+	History []*engine.TestResult
+}
+
+// NewInsights analyses the last of runs, oldest first as returned by
+// history.Store.Recent, against the earlier ones.
+func NewInsights(runs []*engine.TestResult, cfg *config.Config) Insights {
+	insights := Insights{Slow: analytics.Slow(runs, cfg.Analytics)}
+	if n := cfg.Report.TrendRuns; n > 1 && len(runs) > 1 {
+		earlier := runs[:len(runs)-1]
+		insights.History = earlier[max(len(earlier)-(n-1), 0):]
+	}
+	return insights
+}
+
+// HistoryRuns is how many of the latest runs NewInsights needs.
+func HistoryRuns(cfg *config.Config) int {
+	return max(cfg.Analytics.BaselineRuns, cfg.Report.TrendRuns) + 1
+}
+
+// NewNameData describes a run for the report.name template. Git details are
+// left empty outside of a repository.
+func NewNameData(result *engine.TestResult, profile string) NameData {
+	data := NameData{
+		Time:      result.Timestamp.Format("20060102-150405"),
+		Timestamp: result.Timestamp,
+		Profile:   profile,
+	}
+	if commit, err := git.Head("."); err == nil {
+		data.Commit = commit[:min(len(commit), 7)]
+		data.Branch, _ = git.Branch(".")
+	}
+	return data
+}
+
+// Write writes the reports of a run in the configured formats to the
+// archive and publishes them. It returns the paths written; a format that
+// fails does not keep the others from being written.
+func Write(cfg *config.Config, result *engine.TestResult, insights Insights) ([]string, error) {
+	if len(cfg.Report.Formats) == 0 {
+		return nil, nil
+	}
+	archive, err := NewArchive(cfg.Report)
+	if err != nil {
+		return nil, err
+	}
+	base, err := archive.Base(NewNameData(result, cfg.Profile))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	var errs []error
+	for _, format := range cfg.Report.Formats {
+		var path string
+		switch format {
+		case "html":
+			path = base + ".html"
+			err = GenerateHTML(result, insights, path)
+		case "pdf":
+			path = base + ".pdf"
+			err = GeneratePDF(result, insights, path)
+		case "markdown":
+			path = base + ".md"
+			err = writeMarkdown(result, cfg.Report.Markdown, path)
+		case "json":
+			path = base + ".json"
+			err = writeJSON(result, path)
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s report: %w", format, err))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) > 0 {
+		if err := archive.Publish(paths); err != nil {
+			errs = append(errs, fmt.Errorf("report archive: %w", err))
+		}
+	}
+	return paths, errors.Join(errs...)
+}
+
+// writeJSON writes the run as saved in the history, for "devtestrider diff"
+// and other tools.
+func writeJSON(result *engine.TestResult, path string) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// writeMarkdown writes the Markdown report to path and, with
+// step_summary on, appends it to the GitHub Actions step summary.
+func writeMarkdown(result *engine.TestResult, cfg config.MarkdownConfig, path string) error {
+	// Without a baseline yet, e.g. before the first run on the main
+	// branch, there are no deltas
+	var baseline *engine.TestResult
+	if cfg.Baseline != "" {
+		var err error
+		baseline, err = history.ReadFile(cfg.Baseline)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	md := Markdown(result, baseline, cfg.OutputLines)
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		return err
+	}
+
+	summary := os.Getenv("GITHUB_STEP_SUMMARY")
+	if !cfg.StepSummary || summary == "" {
+		return nil
+	}
+	f, err := os.OpenFile(summary, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(md + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,312 @@
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Project is what Inspect learned about the project layout.
+type Project struct {
+	Modules   []engine.Module
+	Paths     []string // Directories to watch: the modules', or the root
+	Vendor    bool
+	TestData  []string // testdata directories
+	BuildTags []string // Tags from //go:build lines of test files
+	ReportDir string
+	Ignore    []string
+	SchemaURL string
+}
+
+// Directories that are never interesting to watch.
+var alwaysIgnore = []string{".git", ".idea", ".vscode"}
+
+// Report directories that are reused if they already exist.
+var reportDirs = []string{"reports", "test-reports", ".reports"}
+
+// Inspect walks the project at root to pick watch, ignore and report
+// settings for a new testrider.yml.
+func Inspect(root string) (*Project, error) {
+	p := &Project{SchemaURL: config.SchemaURL, ReportDir: "./reports"}
+
+	// Modules vendored by JavaScript packages are not the project's
+	modules, err := engine.DiscoverModules(root, append([]string{"node_modules"}, alwaysIgnore...))
+	if err != nil {
+		return nil, err
+	}
+	p.Modules = modules
+	p.Paths = watchPaths(root, modules)
+
+	tags := make(map[string]bool)
+	ignore := append([]string(nil), alwaysIgnore...)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".idea", ".vscode", "node_modules":
+				return filepath.SkipDir
+			case "vendor":
+				p.Vendor = true
+				return filepath.SkipDir
+			case "testdata":
+				p.TestData = append(p.TestData, rel)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case d.Name() == "package.json":
+			dir := filepath.Dir(rel)
+			ignore = append(ignore, "node_modules")
+			for _, build := range []string{"dist", "build", ".next", "coverage"} {
+				if fi, err := os.Stat(filepath.Join(filepath.Dir(path), build)); err == nil && fi.IsDir() {
+					ignore = append(ignore, filepath.ToSlash(filepath.Join(dir, build)))
+				}
+			}
+		case strings.HasSuffix(d.Name(), "_test.go"):
+			for _, tag := range buildTags(path) {
+				tags[tag] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Vendor {
+		ignore = append(ignore, "vendor")
+	}
+	p.Ignore = unique(ignore)
+
+	for tag := range tags {
+		p.BuildTags = append(p.BuildTags, tag)
+	}
+	sort.Strings(p.BuildTags)
+
+	for _, dir := range reportDirs {
+		if fi, err := os.Stat(filepath.Join(root, dir)); err == nil && fi.IsDir() {
+			p.ReportDir = "./" + dir
+			break
+		}
+	}
+	p.Ignore = append(p.Ignore, strings.TrimPrefix(p.ReportDir, "./"))
+	return p, nil
+}
+
+// watchPaths returns the directories of the modules relative to root,
+// leaving out those nested in another, so that directories outside every
+// module, such as a frontend, are not watched. Without modules the whole
+// root is watched.
+func watchPaths(root string, modules []engine.Module) []string {
+	var dirs []string
+	for _, m := range modules {
+		rel, err := filepath.Rel(root, m.Dir)
+		if err != nil {
+			rel = m.Dir
+		}
+		dirs = append(dirs, filepath.ToSlash(rel))
+	}
+	sort.Strings(dirs)
+
+	var paths []string
+	for _, dir := range dirs {
+		nested := false
+		for _, path := range paths {
+			if dir == path || (path == "." && !strings.HasPrefix(dir, "../")) || strings.HasPrefix(dir, path+"/") {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			paths = append(paths, dir)
+		}
+	}
+	if len(paths) == 0 {
+		return []string{"."}
+	}
+	for i, path := range paths {
+		if path != "." && !strings.HasPrefix(path, "../") && !filepath.IsAbs(path) {
+			paths[i] = "./" + path
+		}
+	}
+	return paths
+}
+
+// buildTags returns the identifiers used in a file's //go:build constraint,
+// skipping operating systems and architectures.
+func buildTags(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var tags []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if !strings.HasPrefix(line, "//go:build ") {
+			continue
+		}
+		expr := strings.TrimPrefix(line, "//go:build ")
+		for _, tok := range strings.FieldsFunc(expr, func(r rune) bool { return strings.ContainsRune("!&|() ", r) }) {
+			// Profiles named after tags must not collide with the built-in ones
+			if !platformTags[tok] && !strings.HasPrefix(tok, "go1.") && tok != "default" && tok != "thorough" {
+				tags = append(tags, tok)
+			}
+		}
+	}
+	return tags
+}
+
+var platformTags = map[string]bool{
+	"linux": true, "darwin": true, "windows": true, "freebsd": true, "openbsd": true, "netbsd": true,
+	"unix": true, "js": true, "wasip1": true, "android": true, "ios": true, "plan9": true, "solaris": true,
+	"amd64": true, "arm64": true, "386": true, "arm": true, "wasm": true, "riscv64": true,
+	"cgo": true, "ignore": true,
+}
+
+func unique(items []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+const configTemplate = `# yaml-language-server: $schema={{.SchemaURL}}
+#
+# DevTestrider configuration, generated by "devtestrider init".
+# Check it with "devtestrider config validate"; any key can be overridden
+# with DEVTESTRIDER_* environment variables or command line flags.
+{{- if gt (len .Modules) 1}}
+#
+# Modules found (tests run from each module's directory):
+{{- range .Modules}}
+#   {{.Dir}}  {{.Path}}
+{{- end}}
+{{- end}}
+
+watch:
+  # Directories watched recursively for .go changes
+  paths: [{{quoteList .Paths}}]
+  # Paths containing any of these substrings are ignored
+  ignore: [{{quoteList .Ignore}}]
+{{- if .TestData}}
+  # testdata directories found: {{join .TestData ", "}}
+{{- end}}
+  # auto picks polling on NFS, bind mounts and WSL drives, fsnotify elsewhere
+  backend: auto
+  poll_interval: 1s
+
+report:
+  # Any of: html, pdf, json, markdown
+  formats: ["html"]
+  output_dir: "{{.ReportDir}}"
+  # File names are a Go template over .Time, .Profile, .Branch and .Commit
+  name: report-{{"{{"}}.Time{{"}}"}}
+  # Old reports are deleted beyond these limits; 0 disables a limit
+  retention:
+    keep: 50
+    max_age: 0s
+    max_size_mb: 0
+  markdown:
+    # Run to compare coverage with, e.g. saved from the main branch with
+    # devtestrider run --format json > baseline.json
+    baseline: ""
+    output_lines: 20
+    # Append to $GITHUB_STEP_SUMMARY in GitHub Actions
+    step_summary: true
+  # Runs charted in the HTML report from the history; 0 turns trends off
+  trend_runs: 20
+
+notifications:
+  enable: true
+  # Any of: desktop, browser, slack, webhook, email
+  channels: ["desktop"]
+  rules:
+    # Notify when the suite starts failing or is fixed, and on new failures.
+    # Any of: always, failure, transition, new_failures, coverage_drop
+    when: ["transition", "new_failures"]
+    coverage_drop: 1
+    # quiet_hours: { start: "22:00", end: "07:00" }
+    # rate_limit: 1m
+
+server:
+  port: 8085
+
+git:
+  # Test only the packages touched by commits, checkouts, pulls and rebases
+  enable: true
+  interval: 2s
+
+history:
+  # Finished runs are kept here for slow-test analytics
+  enable: true
+  dir: .devtestrider/history
+  max_runs: 200
+
+analytics:
+  # Flag tests running this many times slower than their median
+  regression_factor: 2
+  min_duration: 100ms
+  slowest: 10
+
+# Profile used for runs; switch with --profile
+profile: default
+profiles:
+  default:
+    short: true
+    # Hung tests are stopped and reported after this long (10m if unset)
+    timeout: 2m
+  thorough:
+    race: true
+    timeout: 10m
+{{- range .BuildTags}}
+  {{.}}:
+    tags: ["{{.}}"]
+{{- end}}
+`
+
+// Render produces a commented testrider.yml for the project.
+func Render(p *Project) ([]byte, error) {
+	tmpl, err := template.New("testrider.yml").Funcs(template.FuncMap{
+		"join": strings.Join,
+		"quoteList": func(items []string) string {
+			quoted := make([]string, len(items))
+			for i, item := range items {
+				quoted[i] = `"` + item + `"`
+			}
+			return strings.Join(quoted, ", ")
+		},
+	}).Parse(configTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, p); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,103 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+func TestInspectAndRender(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"api/go.mod":                "module example.com/api\n",
+		"api/api.go":                "package api\n",
+		"api/db_test.go":            "//go:build integration && !windows\n\npackage api\n",
+		"api/testdata/in.txt":       "input\n",
+		"api/vendor/modules.txt":    "# vendored\n",
+		"tools/go.mod":              "module example.com/tools\n",
+		"tools/gen/gen_test.go":     "//go:build linux\n\npackage gen\n",
+		"web/package.json":          "{}\n",
+		"web/dist/index.html":       "<html></html>\n",
+		"web/node_modules/x/go.mod": "module example.com/x\n",
+		"test-reports/old.html":     "<html></html>\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := Inspect(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"./api", "./tools"}; !slices.Equal(p.Paths, want) {
+		t.Errorf("paths = %q, want the modules %q", p.Paths, want)
+	}
+	if !p.Vendor {
+		t.Error("vendor directory not found")
+	}
+	if want := []string{filepath.Join("api", "testdata")}; !slices.Equal(p.TestData, want) {
+		t.Errorf("testdata = %q, want %q", p.TestData, want)
+	}
+	if want := []string{"integration"}; !slices.Equal(p.BuildTags, want) {
+		t.Errorf("build tags = %q, want %q", p.BuildTags, want)
+	}
+	if p.ReportDir != "./test-reports" {
+		t.Errorf("report dir = %q, want the existing ./test-reports", p.ReportDir)
+	}
+	for _, want := range []string{"node_modules", "web/dist", "vendor", "test-reports"} {
+		if !slices.Contains(p.Ignore, want) {
+			t.Errorf("ignore = %q, want it to contain %q", p.Ignore, want)
+		}
+	}
+
+	data, err := Render(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse("testrider.yml", data)
+	if err != nil {
+		t.Fatalf("rendered config does not parse: %v\n%s", err, data)
+	}
+	if !slices.Equal(cfg.Watch.Paths, p.Paths) || !slices.Equal(cfg.Watch.Ignore, p.Ignore) {
+		t.Errorf("watch = %+v, want paths %q and ignore %q", cfg.Watch, p.Paths, p.Ignore)
+	}
+	if cfg.Report.OutputDir != p.ReportDir {
+		t.Errorf("report.output_dir = %q, want %q", cfg.Report.OutputDir, p.ReportDir)
+	}
+	if tags := cfg.Profiles["integration"].Tags; !slices.Equal(tags, []string{"integration"}) {
+		t.Errorf("integration profile tags = %q", tags)
+	}
+}
+
+func TestWatchPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		dirs []string
+		want []string
+	}{
+		{"no modules", nil, []string{"."}},
+		{"root module", []string{".", "tools"}, []string{"."}},
+		{"nested modules", []string{"svc/b", "svc/a", "svc/a/plugin"}, []string{"./svc/a", "./svc/b"}},
+		{"workspace module outside the root", []string{".", "../shared"}, []string{".", "../shared"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var modules []engine.Module
+			for _, dir := range tt.dirs {
+				modules = append(modules, engine.Module{Path: "example.com/" + dir, Dir: filepath.Join("root", filepath.FromSlash(dir))})
+			}
+			if got := watchPaths("root", modules); !slices.Equal(got, tt.want) {
+				t.Errorf("watchPaths(%q) = %q, want %q", tt.dirs, got, tt.want)
+			}
+		})
+	}
+}
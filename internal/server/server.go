@@ -2,16 +2,23 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/ismailtsdln/DevTestrider/internal/analytics"
 	"github.com/ismailtsdln/DevTestrider/internal/config"
 	"github.com/ismailtsdln/DevTestrider/internal/engine"
+	"github.com/ismailtsdln/DevTestrider/internal/history"
 )
 
 type Server struct {
@@ -20,6 +27,10 @@ type Server struct {
 	clients    map[chan string]bool
 	mu         sync.Mutex
 	LastResult *engine.TestResult
+	logger     *log.Logger
+
+	history   *history.Store
+	analytics config.AnalyticsConfig
 }
 
 func NewServer(cfg config.ServerConfig) *Server {
@@ -27,13 +38,14 @@ func NewServer(cfg config.ServerConfig) *Server {
 		Router:  chi.NewRouter(),
 		Config:  cfg,
 		clients: make(map[chan string]bool),
+		logger:  log.New(os.Stdout, "", log.LstdFlags),
 	}
 	s.setupRoutes()
 	return s
 }
 
 func (s *Server) setupRoutes() {
-	s.Router.Use(middleware.Logger)
+	s.Router.Use(middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: s.logger}))
 	s.Router.Use(middleware.Recoverer)
 
 	s.Router.Use(cors.Handler(cors.Options{
@@ -49,6 +61,9 @@ func (s *Server) setupRoutes() {
 	s.Router.Route("/api", func(r chi.Router) {
 		r.Get("/events", s.handleEvents)
 		r.Get("/results/latest", s.handleLatestResult)
+		r.Get("/analytics/slow", s.handleSlow)
+		r.Get("/runs", s.handleRuns)
+		r.Get("/runs/compare", s.handleCompare)
 	})
 
 	// Serve Static Files (Frontend)
@@ -60,12 +75,27 @@ func (s *Server) setupRoutes() {
 }
 
 func (s *Server) Start() error {
-	addr := fmt.Sprintf(":%d", s.Config.Port)
+	addr := fmt.Sprintf(":%d", s.port())
+	s.logger.Printf("Starting server on %s", s.URL())
+	return http.ListenAndServe(addr, s.Router)
+}
+
+// SetLogOutput redirects the request log, e.g. to io.Discard while the TUI
+// owns the terminal.
+func (s *Server) SetLogOutput(w io.Writer) {
+	s.logger.SetOutput(w)
+}
+
+// URL is the address of the dashboard.
+func (s *Server) URL() string {
+	return fmt.Sprintf("http://localhost:%d", s.port())
+}
+
+func (s *Server) port() int {
 	if s.Config.Port == 0 {
-		addr = ":8080"
+		return 8080
 	}
-	fmt.Printf("Starting server on http://localhost%s\n", addr)
-	return http.ListenAndServe(addr, s.Router)
+	return s.Config.Port
 }
 
 func (s *Server) handleLatestResult(w http.ResponseWriter, r *http.Request) {
@@ -80,12 +110,131 @@ func (s *Server) handleLatestResult(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(s.LastResult)
 }
 
+// SetHistory serves analytics computed from the runs in store. It may be
+// called again, e.g. after a config reload.
+func (s *Server) SetHistory(store *history.Store, cfg config.AnalyticsConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = store
+	s.analytics = cfg
+}
+
+// handleSlow reports the slowest tests and duration regressions of the
+// latest saved run. The limit and factor query parameters override the
+// configured leaderboard length and regression factor.
+func (s *Server) handleSlow(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	store, cfg := s.history, s.analytics
+	s.mu.Unlock()
+
+	if store == nil {
+		http.Error(w, "run history is disabled", http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		cfg.Slowest = limit
+	}
+	if v := query.Get("factor"); v != "" {
+		factor, err := strconv.ParseFloat(v, 64)
+		if err != nil || factor <= 1 {
+			http.Error(w, fmt.Sprintf("invalid factor %q (want a number greater than 1)", v), http.StatusBadRequest)
+			return
+		}
+		cfg.RegressionFactor = factor
+	}
+
+	runs, err := store.Recent(cfg.BaselineRuns + 1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analytics.Slow(runs, cfg))
+}
+
+// handleRuns lists the IDs of the saved runs, oldest first.
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	store := s.history
+	s.mu.Unlock()
+
+	if store == nil {
+		http.Error(w, "run history is disabled", http.StatusNotFound)
+		return
+	}
+	ids, err := store.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ids)
+}
+
+// handleCompare compares two saved runs, the base and head query
+// parameters, which default to the last two runs. Both take run IDs,
+// "latest" or "latest~N". Each is taken as of that run, with packages it did
+// not test at their last result, so partial watch-mode runs compare whole.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	store := s.history
+	s.mu.Unlock()
+
+	if store == nil {
+		http.Error(w, "run history is disabled", http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	refs := []string{query.Get("base"), query.Get("head")}
+	if refs[0] == "" {
+		refs[0] = "latest~1"
+	}
+	if refs[1] == "" {
+		refs[1] = "latest"
+	}
+
+	ids := make([]string, 2)
+	runs := make([]*engine.TestResult, 2)
+	for i, ref := range refs {
+		id, err := store.Resolve(ref)
+		var until []*engine.TestResult
+		if err == nil {
+			until, err = store.Until(id)
+		}
+		if errors.Is(err, history.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ids[i], runs[i] = id, analytics.Snapshot(until)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Base string `json:"base"`
+		Head string `json:"head"`
+		*analytics.Comparison
+	}{ids[0], ids[1], analytics.Compare(runs[0], runs[1])})
+}
+
 func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
-	messageChan := make(chan string)
+	messageChan := make(chan string, clientBuffer)
 	s.mu.Lock()
 	s.clients[messageChan] = true
 	s.mu.Unlock()
@@ -97,6 +246,11 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 		close(messageChan)
 	}()
 
+	// Send the headers now so the client sees the stream open
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
 	notify := r.Context().Done()
 
 	for {
@@ -104,7 +258,7 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 		case <-notify:
 			return
 		case msg := <-messageChan:
-			fmt.Fprintf(w, "data: %s\n\n", msg)
+			fmt.Fprint(w, msg)
 			if f, ok := w.(http.Flusher); ok {
 				f.Flush()
 			}
@@ -127,14 +281,34 @@ func (s *Server) Broadcast(result *engine.TestResult) {
 		s.mu.Unlock()
 		return
 	}
-	msg := string(data)
+	s.send(fmt.Sprintf("data: %s\n\n", data))
+	s.mu.Unlock()
+}
+
+// Publish sends a named SSE event (e.g. "config") to every client. Unnamed
+// messages remain reserved for test results.
+func (s *Server) Publish(event string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.send(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data))
+}
+
+// clientBuffer is how many messages may be queued for a client, enough for a
+// result and the notifications and config events published with it.
+const clientBuffer = 16
+
+// send must be called with s.mu held.
+func (s *Server) send(msg string) {
 	for client := range s.clients {
 		select {
 		case client <- msg:
 		default:
-			// Client blocked, skip
+			// Client fell behind by a whole buffer, skip
 		}
 	}
-	s.mu.Unlock()
 }
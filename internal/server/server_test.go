@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ismailtsdln/DevTestrider/internal/config"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+func TestEventsDeliversResultAndNotification(t *testing.T) {
+	s := NewServer(config.ServerConfig{})
+	s.SetLogOutput(io.Discard)
+	ts := httptest.NewServer(s.Router)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Wait for the client to be registered
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.clients)
+		s.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A run publishes its result and its notification back to back, before
+	// the handler has had a chance to write the first
+	for i := 0; i < 3; i++ {
+		s.Broadcast(&engine.TestResult{TotalTests: i})
+		s.Publish("notification", map[string]int{"run": i})
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	var events []string
+	for len(events) < 6 {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream ended after %q", events)
+			}
+			if strings.HasPrefix(line, "event: ") || strings.HasPrefix(line, "data: {\"timestamp\"") {
+				events = append(events, line)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("got %d of 6 events: %q", len(events), events)
+		}
+	}
+	for i, event := range events {
+		result := strings.HasPrefix(event, "data: ")
+		if want := i%2 == 0; result != want {
+			t.Errorf("event %d = %q, want results and notifications alternating", i, event)
+		}
+	}
+}
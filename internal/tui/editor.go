@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openEditor opens the selected test's failure location in $VISUAL or
+// $EDITOR, suspending the TUI while the editor runs.
+func (m *model) openEditor() tea.Cmd {
+	r := m.selected()
+	if r == nil || r.test == nil {
+		m.status, m.statusWarn = "Select a test to open it in the editor", true
+		return nil
+	}
+	file, line, ok := r.test.Location()
+	if !ok {
+		m.status, m.statusWarn = fmt.Sprintf("%s reported no file:line", r.test.Name), true
+		return nil
+	}
+
+	if !filepath.IsAbs(file) {
+		dir, err := m.packageDir(r.pkg)
+		if err != nil {
+			m.status, m.statusWarn = fmt.Sprintf("Cannot locate %s: %v", r.pkg.name, err), true
+			return nil
+		}
+		file = filepath.Join(dir, file)
+	}
+	cmd, err := editorCommand(file, line)
+	if err != nil {
+		m.status, m.statusWarn = err.Error(), true
+		return nil
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return editorDoneMsg{err} })
+}
+
+// packageDir asks the go command for the directory of a package, from the
+// directory of its module in multi-module projects.
+func (m *model) packageDir(pkg *pkgView) (string, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.Dir}}", pkg.name)
+	if mod, ok := m.modules[pkg.module]; ok {
+		cmd.Dir = mod.Dir
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// editorCommand builds the command opening file at line, using the line
+// syntax of the configured editor.
+func editorCommand(file string, line int) (*exec.Cmd, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return nil, errors.New("$EDITOR is empty")
+	}
+
+	switch filepath.Base(args[0]) {
+	case "code", "code-insiders", "codium", "cursor":
+		args = append(args, "--goto", fmt.Sprintf("%s:%d", file, line))
+	case "subl", "zed", "hx", "helix":
+		args = append(args, fmt.Sprintf("%s:%d", file, line))
+	default:
+		// vi, vim, nvim, nano, emacs, micro and most others
+		args = append(args, fmt.Sprintf("+%d", line), file)
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
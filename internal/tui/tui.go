@@ -0,0 +1,490 @@
+// Package tui is the interactive terminal interface started with --tui. It
+// shows a live package/test tree, the output of the selected test and run
+// history, and drives reruns through the orchestrator.
+package tui
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+// Runs kept for the history sparklines.
+const historySize = 40
+
+// Controller is the part of the orchestrator the key bindings use.
+type Controller interface {
+	RerunAll()
+	RerunFailed()
+	UseProfile(name string)
+}
+
+// TUI runs the terminal interface. It implements orchestrator.Output, and
+// Progress is meant for engine.Runner.Progress.
+type TUI struct {
+	program *tea.Program
+}
+
+// New creates the TUI; profiles are the names "p" cycles through.
+func New(ctl Controller, profiles []string, active string) *TUI {
+	sort.Strings(profiles)
+
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "filter packages and tests"
+
+	m := &model{
+		ctl:      ctl,
+		profiles: profiles,
+		profile:  active,
+		packages: make(map[string]*pkgView),
+		expanded: make(map[string]bool),
+		filter:   filter,
+		detail:   viewport.New(0, 0),
+	}
+	return &TUI{program: tea.NewProgram(m, tea.WithAltScreen())}
+}
+
+// Run shows the interface until the user quits.
+func (t *TUI) Run() error {
+	_, err := t.program.Run()
+	return err
+}
+
+// Quit closes the interface, making Run return.
+func (t *TUI) Quit() {
+	t.program.Quit()
+}
+
+func (t *TUI) RunStarted(label, detail string) {
+	t.program.Send(runStartedMsg{label: label, detail: detail})
+}
+
+func (t *TUI) Info(label, detail string) {
+	t.program.Send(logMsg{text: strings.TrimSpace(label + " " + detail)})
+}
+
+func (t *TUI) Warn(label, detail string) {
+	t.program.Send(logMsg{text: strings.TrimSpace(label + " " + detail), warn: true})
+}
+
+func (t *TUI) Result(result *engine.TestResult) {
+	t.program.Send(resultMsg{result})
+}
+
+// Progress feeds a live "go test" event to the tree.
+func (t *TUI) Progress(event engine.GoTestEvent) {
+	t.program.Send(eventMsg(event))
+}
+
+// Writer returns a writer showing each line in the status bar, for
+// log.SetOutput while the TUI owns the screen.
+func (t *TUI) Writer() io.Writer {
+	return logWriter{t}
+}
+
+type logWriter struct{ t *TUI }
+
+func (w logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSpace(string(p)), "\n") {
+		w.t.program.Send(logMsg{text: line, warn: true})
+	}
+	return len(p), nil
+}
+
+type (
+	runStartedMsg struct{ label, detail string }
+	eventMsg      engine.GoTestEvent
+	resultMsg     struct{ result *engine.TestResult }
+	logMsg        struct {
+		text string
+		warn bool
+	}
+	editorDoneMsg struct{ err error }
+)
+
+// pkgView is a package as shown in the tree, updated live during a run.
+type pkgView struct {
+	name            string
+	module          string
+	status          string // RUN while its tests are running, then PASS, FAIL or SKIP
+	duration        float64
+	coverage        float64
+	tests           []*engine.TestCase
+	coverageHistory []float64
+	buildErrors     []engine.ValidationIssue
+	stack           []string
+	hung            *engine.HungTest
+}
+
+// parent reports whether the named test has subtests. The tree is kept flat
+// here since tests arrive one event at a time.
+func (p *pkgView) parent(name string) bool {
+	for _, test := range p.tests {
+		if strings.HasPrefix(test.Name, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// failingSubtest reports whether a subtest of the named test failed.
+func (p *pkgView) failingSubtest(name string) bool {
+	for _, test := range p.tests {
+		if test.Status == "FAIL" && strings.HasPrefix(test.Name, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// run summarizes a finished run for the sparklines.
+type run struct {
+	duration float64
+	passed   int
+	failed   int
+}
+
+// row is one visible line of the tree.
+type row struct {
+	pkg  *pkgView
+	test *engine.TestCase // nil for a package row
+}
+
+type model struct {
+	ctl      Controller
+	profiles []string
+	profile  string
+
+	packages map[string]*pkgView
+	modules  map[string]*engine.ModuleResult
+	history  []run
+	expanded map[string]bool
+
+	// Live run state
+	running  bool
+	trigger  string
+	started  time.Time
+	touched  map[string]bool // packages reset by the current run
+	finished int
+	passed   int
+	failed   int
+
+	rows      []row
+	cursor    int
+	filter    textinput.Model
+	filtering bool
+	detail    viewport.Model
+	detailKey string
+
+	status     string
+	statusWarn bool
+
+	width, height int
+}
+
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.layout()
+
+	case tea.KeyMsg:
+		if m.filtering {
+			return m, m.updateFilter(msg)
+		}
+		cmd := m.handleKey(msg)
+		m.refresh()
+		return m, cmd
+
+	case runStartedMsg:
+		m.running = true
+		m.trigger = strings.TrimSpace(msg.label + " " + msg.detail)
+		m.started = time.Now()
+		m.touched = make(map[string]bool)
+		m.finished, m.passed, m.failed = 0, 0, 0
+
+	case eventMsg:
+		m.applyEvent(engine.GoTestEvent(msg))
+
+	case resultMsg:
+		m.applyResult(msg.result)
+
+	case logMsg:
+		m.status, m.statusWarn = msg.text, msg.warn
+
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.status, m.statusWarn = fmt.Sprintf("Editor: %v", msg.err), true
+		}
+	}
+
+	m.refresh()
+	return m, nil
+}
+
+func (m *model) handleKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.rows) - 1
+	case "enter", " ":
+		if r := m.selected(); r != nil && r.test == nil {
+			m.expanded[r.pkg.name] = !m.expanded[r.pkg.name]
+		}
+	case "right", "l":
+		if r := m.selected(); r != nil {
+			m.expanded[r.pkg.name] = true
+		}
+	case "left", "h":
+		if r := m.selected(); r != nil {
+			m.expanded[r.pkg.name] = false
+		}
+	case "pgdown", "ctrl+d":
+		m.detail.HalfPageDown()
+	case "pgup", "ctrl+u":
+		m.detail.HalfPageUp()
+	case "/":
+		m.filtering = true
+		return m.filter.Focus()
+	case "esc":
+		m.filter.SetValue("")
+	case "a":
+		return m.control(m.ctl.RerunAll)
+	case "f":
+		return m.control(m.ctl.RerunFailed)
+	case "p":
+		if len(m.profiles) == 0 {
+			break
+		}
+		next := m.profiles[0]
+		for i, name := range m.profiles {
+			if name == m.profile {
+				next = m.profiles[(i+1)%len(m.profiles)]
+			}
+		}
+		m.profile = next
+		return m.control(func() { m.ctl.UseProfile(next) })
+	case "e":
+		return m.openEditor()
+	}
+	return nil
+}
+
+// control calls the orchestrator off the UI goroutine, since it blocks
+// while a run is in progress.
+func (m *model) control(fn func()) tea.Cmd {
+	return func() tea.Msg {
+		fn()
+		return nil
+	}
+}
+
+func (m *model) updateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		m.filtering = false
+		m.filter.Blur()
+		return nil
+	case "esc":
+		m.filtering = false
+		m.filter.Blur()
+		m.filter.SetValue("")
+		m.refresh()
+		return nil
+	}
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	m.cursor = 0
+	m.refresh()
+	return cmd
+}
+
+func (m *model) move(delta int) {
+	m.cursor = max(0, min(len(m.rows)-1, m.cursor+delta))
+}
+
+func (m *model) selected() *row {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+	return &m.rows[m.cursor]
+}
+
+// applyEvent updates the tree from a live "go test" event.
+func (m *model) applyEvent(event engine.GoTestEvent) {
+	if event.Package == "" {
+		return
+	}
+	pkg := m.packages[event.Package]
+	if pkg == nil {
+		pkg = &pkgView{name: event.Package}
+		m.packages[event.Package] = pkg
+	}
+	if m.touched != nil && !m.touched[pkg.name] {
+		m.touched[pkg.name] = true
+		pkg.status = "RUN"
+		pkg.tests = nil
+	}
+
+	switch event.Action {
+	case "run":
+		if event.Test != "" {
+			pkg.tests = append(pkg.tests, &engine.TestCase{Name: event.Test, Status: "RUN"})
+		}
+	case "pass", "fail", "skip":
+		status := strings.ToUpper(event.Action)
+		if event.Test == "" {
+			pkg.status = status
+			pkg.duration = event.Elapsed
+			m.finished++
+			return
+		}
+		for _, test := range pkg.tests {
+			if test.Name == event.Test {
+				test.Status, test.Duration = status, event.Elapsed
+			}
+		}
+		if pkg.parent(event.Test) {
+			return
+		}
+		switch status {
+		case "PASS":
+			m.passed++
+		case "FAIL":
+			m.failed++
+		}
+	}
+}
+
+// applyResult replaces the live state of the run's packages with the final
+// result, which also carries test output and coverage.
+func (m *model) applyResult(result *engine.TestResult) {
+	m.running = false
+	m.touched = nil
+	if result.Modules != nil {
+		m.modules = result.Modules
+	}
+
+	for name, res := range result.Packages {
+		pkg := m.packages[name]
+		if pkg == nil {
+			pkg = &pkgView{name: name}
+			m.packages[name] = pkg
+		}
+		pkg.module = res.Module
+		pkg.status = res.Status
+		pkg.duration = res.Duration
+		pkg.coverage = res.Coverage
+		pkg.tests = res.AllTests()
+		pkg.buildErrors = res.BuildErrors
+		pkg.stack = res.Stack
+		pkg.hung = res.Hung
+		pkg.coverageHistory = appendHistory(pkg.coverageHistory, res.Coverage)
+		// Failures are what the user wants to see first
+		if res.Failed() {
+			m.expanded[name] = true
+		}
+	}
+
+	m.history = append(m.history, run{duration: result.Duration, passed: result.PassedTests, failed: result.FailedTests})
+	if len(m.history) > historySize {
+		m.history = m.history[len(m.history)-historySize:]
+	}
+
+	status := "passed"
+	if !result.Success {
+		status = "failed"
+	}
+	m.status = fmt.Sprintf("Run %s: %d passed, %d failed, %d skipped in %.1fs",
+		status, result.PassedTests, result.FailedTests, result.SkippedTests, result.Duration)
+	m.statusWarn = !result.Success
+}
+
+func appendHistory(values []float64, v float64) []float64 {
+	values = append(values, v)
+	if len(values) > historySize {
+		values = values[len(values)-historySize:]
+	}
+	return values
+}
+
+// refresh rebuilds the visible rows and the detail pane.
+func (m *model) refresh() {
+	var selectedPkg, selectedTest string
+	if r := m.selected(); r != nil {
+		selectedPkg = r.pkg.name
+		if r.test != nil {
+			selectedTest = r.test.Name
+		}
+	}
+
+	query := strings.ToLower(m.filter.Value())
+	names := make([]string, 0, len(m.packages))
+	for name := range m.packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m.rows = m.rows[:0]
+	for _, name := range names {
+		pkg := m.packages[name]
+		pkgMatch := query == "" || strings.Contains(strings.ToLower(name), query)
+		var tests []*engine.TestCase
+		for _, test := range pkg.tests {
+			if pkgMatch || strings.Contains(strings.ToLower(test.Name), query) {
+				tests = append(tests, test)
+			}
+		}
+		if !pkgMatch && len(tests) == 0 {
+			continue
+		}
+		m.rows = append(m.rows, row{pkg: pkg})
+		if m.expanded[name] || (query != "" && !pkgMatch) {
+			for _, test := range tests {
+				m.rows = append(m.rows, row{pkg: pkg, test: test})
+			}
+		}
+	}
+
+	// Keep the cursor on the same item when rows move
+	for i, r := range m.rows {
+		if r.pkg.name == selectedPkg && ((r.test == nil && selectedTest == "") || (r.test != nil && r.test.Name == selectedTest)) {
+			m.cursor = i
+			break
+		}
+	}
+	m.move(0)
+
+	// Start at the top of the output whenever another item is selected
+	key := ""
+	if r := m.selected(); r != nil {
+		key = r.pkg.name
+		if r.test != nil {
+			key += " " + r.test.Name
+		}
+	}
+	if key != m.detailKey {
+		m.detailKey = key
+		m.detail.GotoTop()
+	}
+	m.detail.SetContent(m.detailContent())
+}
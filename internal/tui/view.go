@@ -0,0 +1,310 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ismailtsdln/DevTestrider/internal/engine"
+)
+
+var (
+	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FAFAFA")).Background(lipgloss.Color("#7D56F4")).Padding(0, 1)
+	infoStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
+	passStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("160"))
+	skipStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
+	dimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	cursorStyle = lipgloss.NewStyle().Background(lipgloss.Color("236"))
+	paneStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("238"))
+)
+
+const helpText = "↑/↓ move  enter expand  / filter  a rerun all  f rerun failed  p profile  e edit  pgup/pgdn scroll  q quit"
+
+// Lines taken by the header, history, status and help lines, plus the pane
+// borders.
+const chromeHeight = 4 + 2
+
+// layout sizes the detail pane to the window.
+func (m *model) layout() {
+	m.detail.Width = m.detailWidth() - 2
+	m.detail.Height = m.paneHeight() - 2
+}
+
+func (m *model) treeWidth() int   { return m.width * 11 / 20 }
+func (m *model) detailWidth() int { return m.width - m.treeWidth() }
+func (m *model) paneHeight() int  { return max(3, m.height-chromeHeight+2) }
+
+func (m *model) View() string {
+	if m.width == 0 {
+		return "Starting..."
+	}
+
+	tree := paneStyle.Width(m.treeWidth() - 2).Height(m.paneHeight() - 2).Render(m.treeView(m.paneHeight() - 2))
+	detail := paneStyle.Width(m.detailWidth() - 2).Height(m.paneHeight() - 2).Render(m.detail.View())
+
+	status := m.status
+	if m.statusWarn {
+		status = failStyle.Render(status)
+	}
+	bottom := dimStyle.Render(helpText)
+	if m.filtering {
+		bottom = m.filter.View()
+	} else if m.filter.Value() != "" {
+		bottom = infoStyle.Render("filter: "+m.filter.Value()) + dimStyle.Render("  (esc to clear)  ") + dimStyle.Render(helpText)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.headerView(),
+		lipgloss.JoinHorizontal(lipgloss.Top, tree, detail),
+		m.historyView(),
+		lipgloss.NewStyle().MaxWidth(m.width).Render(status),
+		lipgloss.NewStyle().MaxWidth(m.width).Render(bottom),
+	)
+}
+
+func (m *model) headerView() string {
+	profile := m.profile
+	if profile == "" {
+		profile = "none"
+	}
+	header := titleStyle.Render("DevTestrider") + " " + dimStyle.Render("profile: ") + profile + "  "
+	if !m.running {
+		return header + dimStyle.Render("watching for changes")
+	}
+
+	total := len(m.touched)
+	done := min(m.finished, total)
+	header += infoStyle.Render(m.trigger) + "  "
+	header += bar(float64(done)/float64(max(total, 1)), 20, infoStyle) + fmt.Sprintf(" %d/%d packages  ", done, total)
+	header += passStyle.Render(fmt.Sprintf("%d passed", m.passed)) + " "
+	if m.failed > 0 {
+		header += failStyle.Render(fmt.Sprintf("%d failed", m.failed)) + " "
+	}
+	return header + dimStyle.Render(time.Since(m.started).Round(100*time.Millisecond).String())
+}
+
+// treeView renders the rows around the cursor that fit in height lines.
+func (m *model) treeView(height int) string {
+	if len(m.rows) == 0 {
+		if m.filter.Value() != "" {
+			return dimStyle.Render("No matches")
+		}
+		return dimStyle.Render("No results yet, save a file or press a")
+	}
+
+	start := 0
+	if m.cursor >= height {
+		start = m.cursor - height + 1
+	}
+	width := m.treeWidth() - 2
+
+	var lines []string
+	for i := start; i < len(m.rows) && i < start+height; i++ {
+		line := m.rowView(m.rows[i], width)
+		if i == m.cursor {
+			line = cursorStyle.Width(width).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *model) rowView(r row, width int) string {
+	if r.test != nil {
+		indent := strings.Repeat("  ", r.test.Depth())
+		name := truncate(indent+r.test.ShortName(), width-16)
+		return fmt.Sprintf("    %s %-*s %7.2fs", statusIcon(r.test.Status), width-16, name, r.test.Duration)
+	}
+
+	arrow := "▸"
+	if m.expanded[r.pkg.name] {
+		arrow = "▾"
+	}
+	coverage := dimStyle.Render("   —      ")
+	if r.pkg.coverage > 0 {
+		coverage = bar(r.pkg.coverage/100, 5, coverageStyle(r.pkg.coverage)) + fmt.Sprintf(" %3.0f%%", r.pkg.coverage)
+	}
+	nameWidth := width - 24
+	return fmt.Sprintf("%s %s %-*s %s %6.2fs", arrow, statusIcon(r.pkg.status), nameWidth, truncate(r.pkg.name, nameWidth), coverage, r.pkg.duration)
+}
+
+// historyView draws sparklines of the recent run durations and failures.
+func (m *model) historyView() string {
+	if len(m.history) == 0 {
+		return dimStyle.Render("history: no runs yet")
+	}
+	durations := make([]float64, len(m.history))
+	failures := make([]float64, len(m.history))
+	for i, run := range m.history {
+		durations[i] = run.duration
+		failures[i] = float64(run.failed)
+	}
+	last := m.history[len(m.history)-1]
+	return fmt.Sprintf("%s %s %.1fs   %s %s %d",
+		dimStyle.Render("duration"), infoStyle.Render(sparkline(durations)), last.duration,
+		dimStyle.Render("failures"), failStyle.Render(sparkline(failures)), last.failed)
+}
+
+// detailContent describes the selected test or package.
+func (m *model) detailContent() string {
+	r := m.selected()
+	if r == nil {
+		return ""
+	}
+	width := max(m.detailWidth()-2, 10)
+
+	if r.test != nil {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s %s\n", statusIcon(r.test.Status), lipgloss.NewStyle().Bold(true).Render(r.test.Name))
+		fmt.Fprintf(&b, "%s\n", dimStyle.Render(r.pkg.name))
+		if file, line, ok := r.test.Location(); ok {
+			fmt.Fprintf(&b, "%s %s:%d  %s\n", dimStyle.Render("at"), file, line, dimStyle.Render("(e to open)"))
+		}
+		b.WriteString("\n")
+		if len(r.test.Output) == 0 {
+			b.WriteString(dimStyle.Render("No output"))
+		}
+		for _, line := range r.test.Output {
+			b.WriteString(lipgloss.NewStyle().Width(width).Render(line) + "\n")
+		}
+		return b.String()
+	}
+
+	pkg := r.pkg
+	var passed, failed, skipped int
+	for _, test := range pkg.tests {
+		if pkg.parent(test.Name) {
+			continue
+		}
+		switch test.Status {
+		case "PASS":
+			passed++
+		case "FAIL":
+			failed++
+		case "SKIP":
+			skipped++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", statusIcon(pkg.status), lipgloss.NewStyle().Bold(true).Render(pkg.name))
+	switch pkg.status {
+	case engine.StatusBuildFailed:
+		fmt.Fprintln(&b, failStyle.Render("build failed"))
+	case engine.StatusPanic:
+		fmt.Fprintln(&b, failStyle.Render("panicked"))
+	case engine.StatusTimeout:
+		fmt.Fprintln(&b, failStyle.Render("timed out"))
+	}
+	if pkg.module != "" {
+		fmt.Fprintf(&b, "%s %s\n", dimStyle.Render("module"), pkg.module)
+	}
+	fmt.Fprintf(&b, "\n%d passed, %d failed, %d skipped in %.2fs\n\n", passed, failed, skipped, pkg.duration)
+	fmt.Fprintf(&b, "%s %s %.1f%%\n", dimStyle.Render("coverage"), bar(pkg.coverage/100, 20, coverageStyle(pkg.coverage)), pkg.coverage)
+	if len(pkg.coverageHistory) > 1 {
+		fmt.Fprintf(&b, "%s  %s\n", dimStyle.Render("history"), infoStyle.Render(sparkline(pkg.coverageHistory)))
+	}
+
+	var failing []string
+	for _, test := range pkg.tests {
+		if test.Status == "FAIL" && !pkg.failingSubtest(test.Name) {
+			failing = append(failing, "  "+failStyle.Render("✗ ")+test.Name)
+		}
+	}
+	if len(failing) > 0 {
+		fmt.Fprintf(&b, "\n%s\n%s\n", failStyle.Render("Failing tests"), strings.Join(failing, "\n"))
+	}
+	if len(pkg.buildErrors) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", failStyle.Render("Build errors"))
+		for _, err := range pkg.buildErrors {
+			fmt.Fprintf(&b, "  %s %s\n", dimStyle.Render(fmt.Sprintf("%s:%d", err.File, err.Line)), err.Message)
+		}
+	}
+	if h := pkg.hung; h != nil {
+		fmt.Fprintf(&b, "\n%s %s\n", failStyle.Render("Hung"), dimStyle.Render(fmt.Sprintf("after %.1fs, timeout %gs", h.Elapsed, h.Timeout)))
+		if h.Test != "" {
+			fmt.Fprintf(&b, "  %s", h.Test)
+			if h.File != "" {
+				fmt.Fprintf(&b, " %s", dimStyle.Render(fmt.Sprintf("at %s:%d", h.File, h.Line)))
+			}
+			fmt.Fprintf(&b, " [%s]\n", h.State)
+		}
+		for _, g := range h.Goroutines {
+			fmt.Fprintf(&b, "  %s [%s]\n", dimStyle.Render(fmt.Sprintf("goroutine %d", g.ID)), g.State)
+		}
+	}
+	if len(pkg.stack) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", failStyle.Render("Stack"))
+		for _, line := range pkg.stack {
+			fmt.Fprintln(&b, line)
+		}
+	}
+	return b.String()
+}
+
+func statusIcon(status string) string {
+	switch status {
+	case "PASS":
+		return passStyle.Render("✓")
+	case "FAIL", engine.StatusBuildFailed, engine.StatusPanic, engine.StatusTimeout:
+		return failStyle.Render("✗")
+	case "SKIP":
+		return skipStyle.Render("↷")
+	case "RUN":
+		return infoStyle.Render("●")
+	}
+	return dimStyle.Render("·")
+}
+
+func coverageStyle(coverage float64) lipgloss.Style {
+	switch {
+	case coverage > 80:
+		return passStyle
+	case coverage > 50:
+		return skipStyle
+	}
+	return failStyle
+}
+
+// bar draws a width-cell progress bar filled to fraction.
+func bar(fraction float64, width int, style lipgloss.Style) string {
+	fraction = max(0, min(1, fraction))
+	filled := int(fraction*float64(width) + 0.5)
+	return style.Render(strings.Repeat("█", filled)) + dimStyle.Render(strings.Repeat("░", width-filled))
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline scales values between their minimum and maximum.
+func sparkline(values []float64) string {
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		out[i] = sparks[level]
+	}
+	return string(out)
+}
+
+// truncate shortens the plain string s to width cells.
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
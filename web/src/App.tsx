@@ -1,12 +1,102 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { Sidebar } from './components/Sidebar';
 import { Dashboard } from './components/Dashboard';
 import { TestDetails } from './components/TestDetails';
-import { Toaster } from 'react-hot-toast'; // We might need to install this or use a simple one
+import toast, { Toaster } from 'react-hot-toast'; // We might need to install this or use a simple one
+import type { RunPoint, TestResult } from './types';
+
+interface Failure {
+  package: string;
+  test: string;
+}
+
+interface NotificationEvent {
+  title: string;
+  status: string;
+  message: string;
+  failures?: Failure[];
+}
+
+interface ConfigEvent {
+  valid: boolean;
+  error?: string;
+  restart_required?: string[];
+}
 
 // Using a simple state manager or context would be good, but prop drilling is fine for this size
 function App() {
   const [activeTab, setActiveTab] = useState('dashboard');
+  const [focus, setFocus] = useState<Failure | null>(null);
+  const [permission, setPermission] = useState(
+    typeof Notification === 'undefined' ? 'denied' : Notification.permission
+  );
+
+  const [result, setResult] = useState<TestResult | null>(null);
+  const [history, setHistory] = useState<RunPoint[]>([]);
+
+  // One event stream per tab carries results, browser notifications and
+  // config reloads
+  useEffect(() => {
+    const receive = (data: TestResult | null) => {
+      if (!data) return;
+      setResult(data);
+      setHistory(prev => [...prev.slice(-19), {
+        name: new Date(data.timestamp).toLocaleTimeString(),
+        pass: data.passed_tests,
+        fail: data.failed_tests,
+      }]);
+    };
+
+    fetch('/api/results/latest')
+      .then(res => (res.ok ? res.json() : null))
+      .then(receive)
+      .catch(console.error);
+
+    const eventSource = new EventSource('/api/events');
+    eventSource.onmessage = (event) => receive(JSON.parse(event.data));
+
+    // Browser notifications pushed by the "browser" channel
+    eventSource.addEventListener('notification', (event) => {
+      const data: NotificationEvent = JSON.parse((event as MessageEvent).data);
+      const first = data.failures?.[0];
+      const openFailure = () => {
+        setActiveTab('tests');
+        if (first) setFocus({ ...first });
+      };
+
+      if (typeof Notification === 'undefined' || Notification.permission !== 'granted') {
+        const show = data.status === 'failed' ? toast.error : toast.success;
+        show(data.message);
+        return;
+      }
+      const body = first
+        ? `${data.message}\n${first.package}: ${first.test}`
+        : data.message;
+      const popup = new Notification(data.title, { body, icon: '/logo.png', tag: 'devtestrider' });
+      popup.onclick = () => {
+        window.focus();
+        openFailure();
+        popup.close();
+      };
+    });
+
+    // Report testrider.yml reloads pushed by the server
+    eventSource.addEventListener('config', (event) => {
+      const data: ConfigEvent = JSON.parse((event as MessageEvent).data);
+      if (!data.valid) {
+        toast.error(`Config reload failed, keeping previous config:\n${data.error}`, { duration: 10000 });
+      } else if (data.restart_required?.length) {
+        toast(`Config reloaded. Restart to apply: ${data.restart_required.join(', ')}`, { duration: 8000 });
+      } else {
+        toast.success('Config reloaded');
+      }
+    });
+    return () => eventSource.close();
+  }, []);
+
+  const enableNotifications = async () => {
+    setPermission(await Notification.requestPermission());
+  };
 
   return (
     <div className="flex h-screen bg-slate-950 text-slate-50 font-sans selection:bg-indigo-500/30">
@@ -24,6 +114,14 @@ function App() {
               <p className="text-slate-400 mt-1">Real-time Test Intelligence</p>
             </div>
             <div className="flex items-center space-x-4">
+              {permission === 'default' && (
+                <button
+                  onClick={enableNotifications}
+                  className="text-sm text-slate-400 hover:text-slate-200 border border-slate-700 rounded-lg px-3 py-1"
+                >
+                  Enable notifications
+                </button>
+              )}
               <span className="flex h-3 w-3 relative">
                 <span className="animate-ping absolute inline-flex h-full w-full rounded-full bg-emerald-400 opacity-75"></span>
                 <span className="relative inline-flex rounded-full h-3 w-3 bg-emerald-500"></span>
@@ -32,8 +130,8 @@ function App() {
             </div>
           </header>
 
-          {activeTab === 'dashboard' && <Dashboard />}
-          {activeTab === 'tests' && <TestDetails />}
+          {activeTab === 'dashboard' && <Dashboard data={result} history={history} />}
+          {activeTab === 'tests' && <TestDetails result={result} focus={focus} />}
         </div>
       </main>
       <Toaster position="bottom-right" toastOptions={{ style: { background: '#1e293b', color: '#fff' } }} />
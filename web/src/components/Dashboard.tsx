@@ -1,18 +1,7 @@
-import { useEffect, useState } from 'react';
 import { AreaChart, Area, XAxis, YAxis, CartesianGrid, Tooltip, ResponsiveContainer } from 'recharts';
 import { PlayCircle, XCircle, CheckCircle2, Activity } from 'lucide-react';
 import clsx from 'clsx';
-
-// Type definitions matching backend
-interface TestResult {
-  timestamp: string;
-  total_tests: number;
-  passed_tests: number;
-  failed_tests: number;
-  skipped_tests: number;
-  duration: number;
-  success: boolean;
-}
+import type { RunPoint, TestResult } from '../types';
 
 interface StatCardProps {
   title: string;
@@ -37,45 +26,13 @@ const StatCard = ({ title, value, sub, icon: Icon, color }: StatCardProps) => (
   </div>
 );
 
-export function Dashboard() {
-  const [data, setData] = useState<TestResult | null>(null);
-  const [history, setHistory] = useState<{name: string, pass: number, fail: number}[]>([]);
-
-  useEffect(() => {
-     const fetchLatest = async () => {
-      try {
-        const res = await fetch('/api/results/latest');
-        if (res.ok) {
-          const result = await res.json();
-          if (result) {
-              setData(result);
-              setHistory(prev => [...prev.slice(-19), {
-                  name: new Date(result.timestamp).toLocaleTimeString(),
-                  pass: result.passed_tests,
-                  fail: result.failed_tests
-              }]);
-          }
-        }
-      } catch (e) {
-        console.error(e);
-      }
-    };
-
-    // Setup SSE connection
-    const eventSource = new EventSource('/api/events');
-    
-    eventSource.onmessage = (event) => {
-      console.log("Event:", event.data);
-      fetchLatest();
-    };
-
-    fetchLatest();
-
-    return () => {
-      eventSource.close();
-    };
-  }, []);
+interface DashboardProps {
+  data: TestResult | null;
+  // Runs seen since the page was opened, oldest first
+  history: RunPoint[];
+}
 
+export function Dashboard({ data, history }: DashboardProps) {
   return (
     <div className="space-y-6">
       {/* Stats Grid */}
@@ -83,7 +40,7 @@ export function Dashboard() {
         <StatCard 
           title="Total Tests" 
           value={data?.total_tests || 0} 
-          sub="Across 12 packages" 
+          sub={data?.parent_tests ? `Leaf tests, plus ${data.parent_tests} with subtests` : 'Leaf tests'} 
           icon={PlayCircle} 
           color="text-indigo-400" 
         />
@@ -1,54 +1,104 @@
 import { useEffect, useState } from 'react';
 import { ChevronRight, FileCode, CheckCircle2, XCircle, Clock, Percent } from 'lucide-react';
 import clsx from 'clsx';
+import type { PackageResult, TestCase, TestResult } from '../types';
 
-interface TestCase {
-  name: string;
-  duration: number;
-  status: string;
-}
+// Failures that are not plain test failures
+const failureLabels: Record<string, string> = {
+  BUILD_FAIL: 'build failed',
+  PANIC: 'panic',
+  TIMEOUT: 'timed out',
+};
 
-interface PackageResult {
-  name: string;
-  duration: number;
-  status: string;
-  tests: TestCase[];
-  coverage: number;
+interface TestDetailsProps {
+  result: TestResult | null;
+  // Test to reveal, e.g. after clicking a browser notification
+  focus?: { package: string; test: string } | null;
 }
 
-interface TestResult {
-  packages: Record<string, PackageResult>;
-  issues?: string[];
-}
+const testId = (pkg: string, test: string) => `test-${pkg}-${test}`;
+
+const shortName = (name: string) => name.slice(name.lastIndexOf('/') + 1);
+
+const byName = (tests: TestCase[] = []) => [...tests].sort((a, b) => a.name.localeCompare(b.name));
+
+// Subtests count through their leaves; parents only group them
+const countLeaves = (tests: TestCase[] = []): number =>
+  tests.reduce((n, t) => n + (t.subtests?.length ? countLeaves(t.subtests) : 1), 0);
+
+// "TestA/b/c" is shown below "TestA" and "TestA/b"
+const ancestors = (name: string) => {
+  const parts = name.split('/');
+  return parts.slice(1).map((_, i) => parts.slice(0, i + 1).join('/'));
+};
 
-export function TestDetails() {
-  const [result, setResult] = useState<TestResult | null>(null);
+export function TestDetails({ result, focus }: TestDetailsProps) {
   const [expanded, setExpanded] = useState<Record<string, boolean>>({});
+  // Parents with subtests, keyed by testId; failing ones start open
+  const [openTests, setOpenTests] = useState<Record<string, boolean>>({});
 
   useEffect(() => {
-    const fetchLatest = async () => {
-      try {
-        const res = await fetch('/api/results/latest');
-        if (res.ok) {
-          const data = await res.json();
-          setResult(data);
-        }
-      } catch (e) {
-        console.error(e);
-      }
-    };
-
-    fetchLatest();
-    // Subscribe to SSE for updates
-    const eventSource = new EventSource('/api/events');
-    eventSource.onmessage = () => fetchLatest();
-    return () => eventSource.close();
-  }, []);
+    if (!focus) return;
+    setExpanded(prev => ({...prev, [focus.package]: true}));
+    setOpenTests(prev => {
+      const next = {...prev};
+      for (const name of ancestors(focus.test)) next[testId(focus.package, name)] = true;
+      return next;
+    });
+  }, [focus]);
+
+  useEffect(() => {
+    if (!focus || !result) return;
+    document.getElementById(testId(focus.package, focus.test))?.scrollIntoView({ behavior: 'smooth', block: 'center' });
+  }, [focus, result, expanded, openTests]);
 
   const toggleExpand = (name: string) => {
     setExpanded(prev => ({...prev, [name]: !prev[name]}));
   };
 
+  const isOpen = (pkg: string, test: TestCase) => openTests[testId(pkg, test.name)] ?? test.status === 'FAIL';
+
+  const toggleTest = (pkg: string, test: TestCase) => {
+    setOpenTests(prev => ({...prev, [testId(pkg, test.name)]: !isOpen(pkg, test)}));
+  };
+
+  const renderTests = (pkg: string, tests: TestCase[] | undefined, depth: number) => byName(tests).map(test => {
+    const parent = !!test.subtests?.length;
+    const open = parent && isOpen(pkg, test);
+    return (
+      <div key={test.name}>
+        <div
+          id={testId(pkg, test.name)}
+          className={clsx(
+            "flex items-center justify-between py-2 border-b border-slate-800/30 last:border-0",
+            parent && "cursor-pointer hover:bg-slate-800/20",
+            focus?.package === pkg && focus?.test === test.name && "bg-rose-500/10"
+          )}
+          style={{ paddingLeft: `${2.25 + depth * 1.25}rem` }}
+          onClick={parent ? () => toggleTest(pkg, test) : undefined}
+        >
+          <div className="flex items-center gap-3">
+            {parent
+              ? <ChevronRight className={clsx("w-4 h-4 -ml-6 text-slate-500 transition-transform", open ? "rotate-90" : "")} />
+              : null}
+            {test.status === 'PASS'
+              ? <div className="w-2 h-2 rounded-full bg-emerald-500" />
+              : test.status === 'SKIP'
+                ? <div className="w-2 h-2 rounded-full bg-amber-500" />
+                : <div className="w-2 h-2 rounded-full bg-rose-500" />
+            }
+            <span className={clsx("text-sm font-mono", test.status === 'FAIL' ? "text-rose-300" : "text-slate-400")}>
+              {depth === 0 ? test.name : shortName(test.name)}
+            </span>
+            {parent && <span className="text-xs text-slate-500">{countLeaves(test.subtests)} subtests</span>}
+          </div>
+          <span className="text-xs text-slate-500 font-mono">{(test.duration).toFixed(3)}s</span>
+        </div>
+        {open && renderTests(pkg, test.subtests, depth + 1)}
+      </div>
+    );
+  });
+
   if (!result) return <div className="p-8 text-center text-slate-500">Loading test detail...</div>;
 
   // Convert packages map to array
@@ -94,10 +144,17 @@ export function TestDetails() {
                                 : <XCircle className="w-5 h-5 text-rose-500" />
                             }
                             <div>
-                                <h4 className="font-medium text-slate-200">{pkg.name}</h4>
+                                <h4 className="font-medium text-slate-200 flex items-center gap-2">
+                                    {pkg.name}
+                                    {failureLabels[pkg.status] && (
+                                        <span className="px-2 py-0.5 rounded text-xs font-medium bg-rose-500/10 text-rose-400 border border-rose-500/20">
+                                            {failureLabels[pkg.status]}
+                                        </span>
+                                    )}
+                                </h4>
                                 <div className="flex items-center gap-3 text-xs text-slate-500 mt-1">
                                     <span className="flex items-center gap-1"><Clock className="w-3 h-3" /> {(pkg.duration).toFixed(2)}s</span>
-                                    <span className="flex items-center gap-1"><FileCode className="w-3 h-3" /> {pkg.tests?.length || 0} tests</span>
+                                    <span className="flex items-center gap-1"><FileCode className="w-3 h-3" /> {countLeaves(pkg.tests)} tests</span>
                                 </div>
                             </div>
                         </div>
@@ -117,22 +174,32 @@ export function TestDetails() {
                     </div>
                     
                     {/* Expanded Test Cases */}
-                    {expanded[pkg.name] && pkg.tests && pkg.tests.length > 0 && (
-                        <div className="bg-slate-950/30 px-4 py-2 border-t border-slate-800/50">
-                            {pkg.tests.map((test, tIdx) => (
-                                <div key={tIdx} className="flex items-center justify-between py-2 border-b border-slate-800/30 last:border-0 pl-9">
-                                    <div className="flex items-center gap-3">
-                                         {test.status === 'PASS' 
-                                            ? <div className="w-2 h-2 rounded-full bg-emerald-500" />
-                                            : <div className="w-2 h-2 rounded-full bg-rose-500" />
-                                         }
-                                         <span className={clsx("text-sm font-mono", test.status === 'PASS' ? "text-slate-400" : "text-rose-300")}>
-                                            {test.name}
-                                         </span>
-                                    </div>
-                                    <span className="text-xs text-slate-500 font-mono">{(test.duration).toFixed(3)}s</span>
+                    {expanded[pkg.name] && (pkg.build_errors?.length || pkg.stack?.length || pkg.hung) ? (
+                        <div className="bg-slate-950/30 px-4 py-3 border-t border-slate-800/50 space-y-2">
+                            {pkg.build_errors?.map((err, eIdx) => (
+                                <div key={eIdx} className="font-mono text-sm text-rose-300">
+                                    <span className="text-slate-500">{err.file}:{err.line}:</span> {err.message}
                                 </div>
                             ))}
+                            {pkg.hung && (
+                                <div className="font-mono text-sm text-rose-300">
+                                    hung{pkg.hung.test ? ` in ${pkg.hung.test}` : ''}
+                                    {pkg.hung.file && <span className="text-slate-500"> at {pkg.hung.file}:{pkg.hung.line}</span>}
+                                    {pkg.hung.state && <span> [{pkg.hung.state}]</span>}
+                                    <span className="text-slate-500"> after {pkg.hung.elapsed.toFixed(1)}s (timeout {pkg.hung.timeout}s), {pkg.hung.goroutines?.length ?? 0} goroutines in the package</span>
+                                </div>
+                            )}
+                            {pkg.stack && pkg.stack.length > 0 && (
+                                <pre className="font-mono text-xs text-slate-300 bg-slate-950/60 border border-slate-800 rounded p-3 overflow-x-auto max-h-80">
+                                    {pkg.stack.join('\n')}
+                                </pre>
+                            )}
+                        </div>
+                    ) : null}
+
+                    {expanded[pkg.name] && pkg.tests && pkg.tests.length > 0 && (
+                        <div className="bg-slate-950/30 px-4 py-2 border-t border-slate-800/50">
+                            {renderTests(pkg.name, pkg.tests, 0)}
                         </div>
                     )}
                 </div>
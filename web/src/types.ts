@@ -0,0 +1,62 @@
+// Type definitions matching the backend's engine.TestResult
+
+export interface TestCase {
+  name: string;
+  duration: number;
+  status: string;
+  subtests?: TestCase[];
+}
+
+export interface BuildError {
+  file: string;
+  line: number;
+  message: string;
+}
+
+export interface Goroutine {
+  id: number;
+  state: string;
+}
+
+// What a TIMEOUT package was doing when it was stopped
+export interface HungTest {
+  test?: string;
+  timeout: number;
+  elapsed: number;
+  file?: string;
+  line?: number;
+  state?: string;
+  goroutines?: Goroutine[];
+}
+
+export interface PackageResult {
+  name: string;
+  duration: number;
+  // PASS, FAIL, SKIP, BUILD_FAIL, PANIC or TIMEOUT
+  status: string;
+  tests: TestCase[];
+  coverage: number;
+  build_errors?: BuildError[];
+  stack?: string[];
+  hung?: HungTest;
+}
+
+export interface TestResult {
+  timestamp: string;
+  total_tests: number;
+  passed_tests: number;
+  failed_tests: number;
+  skipped_tests: number;
+  parent_tests: number;
+  duration: number;
+  success: boolean;
+  packages: Record<string, PackageResult>;
+  issues?: string[];
+}
+
+// One point of the dashboard's execution trend
+export interface RunPoint {
+  name: string;
+  pass: number;
+  fail: number;
+}